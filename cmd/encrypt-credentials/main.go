@@ -0,0 +1,49 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/sergekovalev/siberia/internal/googleapi"
+)
+
+// Шифрует ключ сервисного аккаунта Google для хранения на диске:
+//
+//	encrypt-credentials -genkey                 - вывести новый ключ шифрования для GOOGLE_CREDENTIALS_KEY
+//	encrypt-credentials credentials.json > credentials.enc
+//
+// Ключ шифрования берется из переменной окружения GOOGLE_CREDENTIALS_KEY
+func main() {
+	genKey := flag.Bool("genkey", false, "вывести новый ключ шифрования")
+	flag.Parse()
+
+	if *genKey {
+		key, err := googleapi.NewCredentialsKey()
+		if err != nil {
+			log.Fatalf("Не удалось создать ключ: %v", err)
+		}
+		fmt.Println(key)
+		return
+	}
+
+	// Читаем учетные данные из файла или стандартного ввода
+	var data []byte
+	var err error
+	if flag.NArg() > 0 {
+		data, err = os.ReadFile(flag.Arg(0))
+	} else {
+		data, err = io.ReadAll(os.Stdin)
+	}
+	if err != nil {
+		log.Fatalf("Не удалось прочитать учетные данные: %v", err)
+	}
+
+	encrypted, err := googleapi.EncryptCredentials(data)
+	if err != nil {
+		log.Fatalf("Не удалось зашифровать учетные данные: %v", err)
+	}
+	os.Stdout.Write(encrypted)
+}
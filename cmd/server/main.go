@@ -9,6 +9,8 @@ import (
 	"github.com/sergekovalev/siberia/internal/config"
 	"github.com/sergekovalev/siberia/internal/googleapi"
 	"github.com/sergekovalev/siberia/internal/handlers"
+	"github.com/sergekovalev/siberia/internal/tlsconfig"
+	"github.com/sergekovalev/siberia/internal/utils"
 )
 
 func main() {
@@ -32,11 +34,14 @@ func main() {
 	}
 
 	// Инициализируем обработчики HTTP-запросов, передавая сервис Google Sheets и конфигурацию
-	handlers.InitHandlers(sheetsService, cfg)
+	if err := handlers.InitHandlers(sheetsService, cfg); err != nil {
+		log.Fatalf("Не удалось инициализировать обработчики: %v", err)
+	}
 
 	// Настраиваем файловый сервер для обслуживания статических файлов из папки "./static"
+	// Страницы отдаются с заголовками безопасности; встраивать их могут сайты, которым разрешен CORS
 	fs := http.FileServer(http.Dir("./static"))
-	http.Handle("/", fs)
+	http.Handle("/", utils.SecurityHeaders(cfg.CORS.AllowedOrigins, fs))
 
 	// Настраиваем HTTP-сервер с таймаутами для чтения, записи и простоя
 	srv := &http.Server{
@@ -46,9 +51,22 @@ func main() {
 		IdleTimeout:  60 * time.Second, // Таймаут простоя соединения
 	}
 
+	// Без сертификата сервер работает по HTTP (например, за обратным прокси с HTTPS)
+	if !cfg.TLS.Enabled() {
+		log.Printf("Сервер запущен на порту %s (HTTP)", cfg.Port)
+		log.Fatal(srv.ListenAndServe())
+	}
+
+	// Сертификат перечитывается при изменении файлов; сертификаты устройств проверяются, если задан центр
+	tlsConfig, err := tlsconfig.New(cfg.TLS)
+	if err != nil {
+		log.Fatalf("Не удалось загрузить сертификаты TLS: %v", err)
+	}
+	srv.TLSConfig = tlsConfig
+
 	// Логируем информацию о запуске сервера
-	log.Printf("Сервер запущен на порту %s", cfg.Port)
+	log.Printf("Сервер запущен на порту %s (HTTPS, TLS %s и выше)", cfg.Port, cfg.TLS.MinVersion)
 
-	// Запускаем сервер и завершаем приложение в случае ошибки
-	log.Fatal(srv.ListenAndServe())
+	// Запускаем сервер и завершаем приложение в случае ошибки; сертификат берется из TLSConfig
+	log.Fatal(srv.ListenAndServeTLS("", ""))
 }
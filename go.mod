@@ -3,6 +3,7 @@ module github.com/sergekovalev/siberia
 go 1.24.0
 
 require (
+	golang.org/x/crypto v0.36.0
 	golang.org/x/oauth2 v0.29.0
 	google.golang.org/api v0.228.0
 )
@@ -23,7 +24,6 @@ require (
 	go.opentelemetry.io/otel v1.34.0 // indirect
 	go.opentelemetry.io/otel/metric v1.34.0 // indirect
 	go.opentelemetry.io/otel/trace v1.34.0 // indirect
-	golang.org/x/crypto v0.36.0 // indirect
 	golang.org/x/net v0.37.0 // indirect
 	golang.org/x/sys v0.31.0 // indirect
 	golang.org/x/text v0.23.0 // indirect
@@ -0,0 +1,509 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/sergekovalev/siberia/internal/store"
+	"github.com/sergekovalev/siberia/internal/utils"
+)
+
+// Роли пользователей
+const (
+	RoleOperator   = "operator"   // Оператор: ввод выпуска, простоев, заявок на ремонт
+	RoleForeman    = "foreman"    // Мастер: справочники, план, заказы, отчеты
+	RoleAccountant = "accountant" // Бухгалтер: табель, отчеты, склад
+	RoleAdmin      = "admin"      // Администратор: все, включая пользователей
+)
+
+// SessionCookie - имя cookie с токеном сессии
+const SessionCookie = "siberia_session"
+
+// Ошибки управления пользователями
+var (
+	ErrInvalidCredentials = errors.New("invalid username or password")
+	ErrUserExists         = errors.New("user already exists")
+	ErrWeakPassword       = errors.New("password must be at least 8 characters long")
+)
+
+// ValidRole проверяет, что роль известна
+func ValidRole(role string) bool {
+	switch role {
+	case RoleOperator, RoleForeman, RoleAccountant, RoleAdmin:
+		return true
+	}
+	return false
+}
+
+// User представляет учетную запись пользователя
+type User struct {
+	ID           string    `json:"id"`           // Идентификатор пользователя
+	Username     string    `json:"username"`     // Имя для входа
+	FullName     string    `json:"fullName"`     // ФИО, как в записях о выпуске и табеле
+	Role         string    `json:"role"`         // Роль
+	PasswordHash string    `json:"passwordHash"` // Хеш пароля (bcrypt)
+	Disabled     bool      `json:"disabled"`     // Учетная запись заблокирована
+	Created      time.Time `json:"created"`      // Дата создания
+
+	PINHash     string     `json:"pinHash,omitempty"`     // Хеш PIN-кода для входа в режиме киоска (bcrypt)
+	FailedPINs  int        `json:"failedPins,omitempty"`  // Неудачных попыток ввода PIN-кода подряд
+	LockedUntil *time.Time `json:"lockedUntil,omitempty"` // Вход по PIN-коду заблокирован до этого времени
+}
+
+// UserInfo - сведения о пользователе без хеша пароля для ответов API
+type UserInfo struct {
+	ID        string `json:"id"`
+	Username  string `json:"username"`
+	FullName  string `json:"fullName"`
+	Role      string `json:"role"`
+	Disabled  bool   `json:"disabled"`
+	HasPIN    bool   `json:"hasPin"`    // Задан PIN-код для режима киоска
+	PINLocked bool   `json:"pinLocked"` // Вход по PIN-коду временно заблокирован
+}
+
+// Info возвращает сведения о пользователе для ответов API
+func (u User) Info() UserInfo {
+	return UserInfo{
+		ID:        u.ID,
+		Username:  u.Username,
+		FullName:  u.FullName,
+		Role:      u.Role,
+		Disabled:  u.Disabled,
+		HasPIN:    u.PINHash != "",
+		PINLocked: u.PINLocked(time.Now()),
+	}
+}
+
+// HasRole проверяет, что у пользователя одна из перечисленных ролей
+func (u User) HasRole(roles ...string) bool {
+	for _, role := range roles {
+		if u.Role == role {
+			return true
+		}
+	}
+	return false
+}
+
+// Session представляет сессию пользователя, вошедшего в систему
+// В файле хранится хеш токена, поэтому утечка файла не позволяет войти под чужой сессией
+type Session struct {
+	TokenHash string    `json:"tokenHash"`       // SHA-256 токена сессии
+	UserID    string    `json:"userId"`          // Пользователь
+	Created   time.Time `json:"created"`         // Время входа
+	Expires   time.Time `json:"expires"`         // Время окончания сессии
+	Kiosk     bool      `json:"kiosk,omitempty"` // Вход по PIN-коду на общем планшете; сессия продлевается при активности
+}
+
+// Options задает время жизни сессий и правила входа по PIN-коду
+type Options struct {
+	SessionTTL     time.Duration // Время жизни обычной сессии
+	KioskIdle      time.Duration // Бездействие, после которого завершается сессия киоска
+	PINMaxAttempts int           // Неудачных попыток ввода PIN-кода до блокировки
+	PINLockout     time.Duration // Длительность блокировки входа по PIN-коду
+	RateLimit      int           // Запросов в минуту от одного пользователя или токена; 0 - без ограничения
+}
+
+// Manager управляет пользователями и сессиями
+type Manager struct {
+	users    *store.Collection[User]
+	sessions *store.Collection[Session]
+	tokens   *store.Collection[APIToken]
+	limiter  *utils.RateLimiter
+	opts     Options
+}
+
+// NewManager загружает пользователей, сессии и API-токены из каталога данных приложения
+func NewManager(dataDir string, opts Options) (*Manager, error) {
+	users, err := store.Open[User](filepath.Join(dataDir, "users.json"))
+	if err != nil {
+		return nil, err
+	}
+	sessions, err := store.Open[Session](filepath.Join(dataDir, "sessions.json"))
+	if err != nil {
+		return nil, err
+	}
+	tokens, err := openTokens(dataDir)
+	if err != nil {
+		return nil, err
+	}
+	m := &Manager{users: users, sessions: sessions, tokens: tokens, limiter: utils.NewRateLimiter(opts.RateLimit), opts: opts}
+
+	// Удаляем сессии, истекшие за время простоя сервера
+	now := time.Now()
+	if _, err := sessions.Delete(func(s Session) bool { return !now.Before(s.Expires) }); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Users возвращает всех пользователей
+func (m *Manager) Users() []User {
+	return m.users.All()
+}
+
+// HasUsers проверяет, что создан хотя бы один пользователь
+func (m *Manager) HasUsers() bool {
+	return len(m.users.All()) > 0
+}
+
+// CreateUser создает пользователя с паролем password и PIN-кодом pin для режима киоска
+// Достаточно одного из них: сотрудник без пароля входит только на планшете киоска.
+// PIN-код можно задать только оператору
+func (m *Manager) CreateUser(username, fullName, role, password, pin string) (User, error) {
+	if pin != "" && role != RoleOperator {
+		return User{}, ErrPINRole
+	}
+	username = strings.TrimSpace(username)
+	if _, exists := m.users.Find(func(u User) bool { return strings.EqualFold(u.Username, username) }); exists {
+		return User{}, ErrUserExists
+	}
+	var hash, pinHash string
+	var err error
+	if password != "" || pin == "" {
+		if hash, err = hashPassword(password); err != nil {
+			return User{}, err
+		}
+	}
+	if pin != "" {
+		if pinHash, err = hashPIN(pin); err != nil {
+			return User{}, err
+		}
+	}
+
+	u := User{
+		ID:           utils.NewID(),
+		Username:     username,
+		FullName:     strings.TrimSpace(fullName),
+		Role:         role,
+		PasswordHash: hash,
+		PINHash:      pinHash,
+		Created:      time.Now(),
+	}
+	if err := m.users.Add(u); err != nil {
+		return User{}, err
+	}
+	return u, nil
+}
+
+// UserChanges содержит изменения учетной записи; пустые значения не изменяются
+type UserChanges struct {
+	FullName string
+	Role     string
+	Password string
+	PIN      string // Новый PIN-код; заодно снимает блокировку входа по PIN-коду
+	Disabled *bool
+}
+
+// UpdateUser изменяет пользователя
+// Блокировка пользователя или смена пароля завершает все его сессии. PIN-код можно задать только оператору;
+// при смене роли оператора PIN-код удаляется вместе с сессиями киоска. Второй результат равен false,
+// если пользователь не найден
+func (m *Manager) UpdateUser(id string, ch UserChanges) (User, bool, error) {
+	var hash, pinHash string
+	var err error
+	if ch.Password != "" {
+		if hash, err = hashPassword(ch.Password); err != nil {
+			return User{}, true, err
+		}
+	}
+	if ch.PIN != "" {
+		if pinHash, err = hashPIN(ch.PIN); err != nil {
+			return User{}, true, err
+		}
+	}
+
+	pinRemoved := false
+	u, found, err := m.users.Update(func(u User) bool { return u.ID == id }, func(u *User) error {
+		if fullName := strings.TrimSpace(ch.FullName); fullName != "" {
+			u.FullName = fullName
+		}
+		if ch.Role != "" {
+			u.Role = ch.Role
+		}
+		if u.Role != RoleOperator {
+			if pinHash != "" {
+				return ErrPINRole
+			}
+			pinRemoved = u.PINHash != ""
+			u.PINHash = ""
+			u.FailedPINs = 0
+			u.LockedUntil = nil
+		}
+		if hash != "" {
+			u.PasswordHash = hash
+		}
+		if pinHash != "" {
+			u.PINHash = pinHash
+			u.FailedPINs = 0
+			u.LockedUntil = nil
+		}
+		if ch.Disabled != nil {
+			u.Disabled = *ch.Disabled
+		}
+		return nil
+	})
+	if err != nil || !found {
+		return u, found, err
+	}
+
+	if hash != "" || u.Disabled {
+		if _, err := m.sessions.Delete(func(s Session) bool { return s.UserID == u.ID }); err != nil {
+			return u, true, err
+		}
+	} else if pinRemoved {
+		if _, err := m.sessions.Delete(func(s Session) bool { return s.UserID == u.ID && s.Kiosk }); err != nil {
+			return u, true, err
+		}
+	}
+	return u, true, nil
+}
+
+// Login проверяет имя пользователя и пароль и создает сессию
+// Возвращает токен сессии, который передается клиенту в cookie
+func (m *Manager) Login(username, password string) (string, User, error) {
+	u, found := m.users.Find(func(u User) bool { return strings.EqualFold(u.Username, strings.TrimSpace(username)) })
+	if !found || u.Disabled {
+		// Сравниваем с фиктивным хешем, чтобы время ответа не выдавало существование пользователя
+		bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+		return "", User{}, ErrInvalidCredentials
+	}
+	if u.PasswordHash == "" {
+		bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+		return "", User{}, ErrInvalidCredentials // Сотрудник входит только по PIN-коду
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(password)); err != nil {
+		return "", User{}, ErrInvalidCredentials
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return "", User{}, err
+	}
+	if err := m.startSession(token, u.ID, time.Now().Add(m.opts.SessionTTL), false); err != nil {
+		return "", User{}, err
+	}
+	return token, u, nil
+}
+
+// startSession сохраняет новую сессию, заодно удаляя истекшие
+func (m *Manager) startSession(token, userID string, expires time.Time, kiosk bool) error {
+	now := time.Now()
+	if _, err := m.sessions.Delete(func(s Session) bool { return !now.Before(s.Expires) }); err != nil {
+		return err
+	}
+	return m.sessions.Add(Session{TokenHash: HashToken(token), UserID: userID, Created: now, Expires: expires, Kiosk: kiosk})
+}
+
+// Logout завершает сессию с указанным токеном
+func (m *Manager) Logout(token string) error {
+	hash := HashToken(token)
+	_, err := m.sessions.Delete(func(s Session) bool { return s.TokenHash == hash })
+	return err
+}
+
+// SessionUser возвращает пользователя действующей сессии с указанным токеном
+// Сессия киоска при каждом обращении продлевается на время бездействия KioskIdle
+func (m *Manager) SessionUser(token string) (User, Session, bool) {
+	if token == "" {
+		return User{}, Session{}, false
+	}
+	hash := HashToken(token)
+	session, found := m.sessions.Find(func(s Session) bool { return s.TokenHash == hash })
+	now := time.Now()
+	if !found || !now.Before(session.Expires) {
+		return User{}, Session{}, false
+	}
+	u, found := m.users.Find(func(u User) bool { return u.ID == session.UserID })
+	if !found || u.Disabled {
+		return User{}, Session{}, false
+	}
+
+	// Продлеваем сессию киоска; файл перезаписываем не чаще раза в несколько секунд
+	if session.Kiosk {
+		if expires := now.Add(m.opts.KioskIdle); expires.Sub(session.Expires) > 5*time.Second {
+			if updated, _, err := m.sessions.Update(func(s Session) bool { return s.TokenHash == hash }, func(s *Session) error {
+				s.Expires = expires
+				return nil
+			}); err == nil {
+				session = updated
+			}
+		}
+	}
+	return u, session, true
+}
+
+// Access описывает, каким ролям разрешено чтение (GET) и изменение (остальные методы) через обработчик,
+// и область, разрешения на которую нужны API-токену
+type Access struct {
+	Read  []string // Роли, которым разрешены GET-запросы
+	Write []string // Роли, которым разрешены POST-запросы
+	Scope string   // Область разрешений API-токенов; пусто - обработчик недоступен по токену
+
+	Kiosk     bool // Обработчик доступен из сессии киоска (ввод с участка)
+	KioskRead bool // Из сессии киоска доступны только GET-запросы (справочники для формы ввода)
+}
+
+// For возвращает права доступа с областью разрешений API-токенов scope
+func (a Access) For(scope string) Access {
+	a.Scope = scope
+	return a
+}
+
+// ForKiosk возвращает права доступа, разрешающие обработчик из сессии киоска
+func (a Access) ForKiosk() Access {
+	a.Kiosk = true
+	return a
+}
+
+// ForKioskRead возвращает права доступа, разрешающие из сессии киоска только чтение
+func (a Access) ForKioskRead() Access {
+	a.KioskRead = true
+	return a
+}
+
+// Require возвращает промежуточный обработчик, который пропускает только пользователей с ролями из access
+// или запросы с API-токеном (Authorization: Bearer или сертификат зарегистрированного устройства),
+// имеющим разрешение scope:read для GET-запросов и scope:write для остальных.
+// Сессия киоска допускается только к обработчикам, отмеченным ForKiosk или ForKioskRead.
+// Без сессии или с недействительным токеном возвращается 401, при недостаточных правах - 403,
+// при превышении числа запросов пользователя или токена - 429
+func (m *Manager) Require(access Access) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			read := r.Method == http.MethodGet || r.Method == http.MethodHead
+
+			// Сессия важнее сертификата устройства: на планшете с сертификатом работает вошедший сотрудник
+			u, session, ok := m.userFromRequest(r)
+			if hasBearer(r) || !ok {
+				if t, presented, valid := m.tokenFromRequest(r); presented {
+					m.serveToken(w, r, t, valid, access, read, next)
+					return
+				}
+			}
+			if !ok {
+				http.Error(w, "Authentication required", http.StatusUnauthorized)
+				return
+			}
+
+			roles := access.Write
+			if read {
+				roles = access.Read
+			}
+			if !u.HasRole(roles...) {
+				http.Error(w, "Access denied", http.StatusForbidden)
+				return
+			}
+			// Сессия общего планшета открывает только ввод с участка и справочники для него
+			if session.Kiosk && !access.Kiosk && !(access.KioskRead && read) {
+				http.Error(w, "Not available in kiosk mode", http.StatusForbidden)
+				return
+			}
+			if ok, retry := m.limiter.Allow(u.ID); !ok {
+				utils.TooManyRequests(w, retry)
+				return
+			}
+			ctx := WithUser(r.Context(), u)
+			if session.Kiosk {
+				ctx = context.WithValue(ctx, kioskKey{}, true)
+			}
+			next(w, r.WithContext(ctx))
+		}
+	}
+}
+
+// serveToken пропускает запрос с API-токеном t, если токен действителен и имеет разрешение на область access
+func (m *Manager) serveToken(w http.ResponseWriter, r *http.Request, t APIToken, valid bool, access Access, read bool, next http.HandlerFunc) {
+	if !valid {
+		http.Error(w, "Invalid or revoked API token", http.StatusUnauthorized)
+		return
+	}
+	if access.Scope == "" {
+		http.Error(w, "Endpoint is not available with API tokens", http.StatusForbidden)
+		return
+	}
+	action := ActionWrite
+	if read {
+		action = ActionRead
+	}
+	if !t.Allows(access.Scope + ":" + action) {
+		http.Error(w, "API token lacks permission "+access.Scope+":"+action, http.StatusForbidden)
+		return
+	}
+	if ok, retry := m.limiter.Allow(t.user().ID); !ok {
+		utils.TooManyRequests(w, retry)
+		return
+	}
+	next(w, r.WithContext(WithUser(r.Context(), t.user())))
+}
+
+// userFromRequest возвращает пользователя и сессию по cookie сессии
+func (m *Manager) userFromRequest(r *http.Request) (User, Session, bool) {
+	cookie, err := r.Cookie(SessionCookie)
+	if err != nil {
+		return User{}, Session{}, false
+	}
+	return m.SessionUser(cookie.Value)
+}
+
+// Ключи пользователя и признака сессии киоска в контексте запроса
+type (
+	userKey  struct{}
+	kioskKey struct{}
+)
+
+// WithUser добавляет пользователя в контекст запроса
+func WithUser(ctx context.Context, u User) context.Context {
+	return context.WithValue(ctx, userKey{}, u)
+}
+
+// UserFromContext возвращает пользователя, выполнившего запрос
+func UserFromContext(ctx context.Context) (User, bool) {
+	u, ok := ctx.Value(userKey{}).(User)
+	return u, ok
+}
+
+// IsKiosk проверяет, что запрос выполнен из сессии киоска (вход по PIN-коду)
+func IsKiosk(ctx context.Context) bool {
+	kiosk, _ := ctx.Value(kioskKey{}).(bool)
+	return kiosk
+}
+
+// HashToken возвращает хеш токена, под которым он хранится в файле
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// dummyHash - хеш для сравнения при входе несуществующего пользователя
+var dummyHash, _ = bcrypt.GenerateFromPassword([]byte("siberia"), bcrypt.DefaultCost)
+
+// hashPassword проверяет длину пароля и возвращает его bcrypt-хеш
+func hashPassword(password string) (string, error) {
+	if len(password) < 8 {
+		return "", ErrWeakPassword
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %v", err)
+	}
+	return string(hash), nil
+}
+
+// newToken создает случайный токен сессии
+func newToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate token: %v", err)
+	}
+	return hex.EncodeToString(b), nil
+}
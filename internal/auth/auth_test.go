@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRequire(t *testing.T) {
+	m := newTestManager(t, Options{SessionTTL: time.Hour, KioskIdle: time.Minute, PINMaxAttempts: 5, PINLockout: time.Hour})
+
+	operator, err := m.CreateUser("ivanov", "Иванов И. И.", RoleOperator, "password123", "1234")
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	if _, err := m.CreateUser("petrov", "Петров П. П.", RoleForeman, "password123", ""); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	operatorSession, _, err := m.Login("ivanov", "password123")
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	foremanSession, _, err := m.Login("petrov", "password123")
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	kioskSession, _, err := m.KioskLogin(operator.ID, "1234")
+	if err != nil {
+		t.Fatalf("KioskLogin: %v", err)
+	}
+	readToken, _, err := m.CreateToken("Станок 1", []string{"production:read"}, "", "admin")
+	if err != nil {
+		t.Fatalf("CreateToken: %v", err)
+	}
+
+	all := []string{RoleOperator, RoleForeman, RoleAccountant, RoleAdmin}
+	entry := Access{Read: all, Write: []string{RoleOperator, RoleForeman, RoleAdmin}, Scope: ScopeProduction}
+	manager := Access{Read: all, Write: []string{RoleForeman, RoleAdmin}, Scope: ScopeReference}
+	reports := Access{Read: []string{RoleForeman, RoleAdmin}}
+
+	tests := []struct {
+		name    string
+		access  Access
+		method  string
+		session string
+		bearer  string
+		want    int
+	}{
+		{"без сессии", entry, http.MethodGet, "", "", http.StatusUnauthorized},
+		{"неизвестная сессия", entry, http.MethodGet, "unknown", "", http.StatusUnauthorized},
+		{"оператор вводит данные", entry, http.MethodPost, operatorSession, "", http.StatusOK},
+		{"оператор меняет справочник", manager, http.MethodPost, operatorSession, "", http.StatusForbidden},
+		{"мастер меняет справочник", manager, http.MethodPost, foremanSession, "", http.StatusOK},
+		{"оператор читает отчет", reports, http.MethodGet, operatorSession, "", http.StatusForbidden},
+		{"киоск: ввод без отметки ForKiosk", entry, http.MethodPost, kioskSession, "", http.StatusForbidden},
+		{"киоск: ввод с отметкой ForKiosk", entry.ForKiosk(), http.MethodPost, kioskSession, "", http.StatusOK},
+		{"киоск: чтение справочника с ForKioskRead", manager.ForKioskRead(), http.MethodGet, kioskSession, "", http.StatusOK},
+		{"киоск: запись в справочник с ForKioskRead", entry.ForKioskRead(), http.MethodPost, kioskSession, "", http.StatusForbidden},
+		{"токен: разрешенное чтение", entry, http.MethodGet, "", readToken, http.StatusOK},
+		{"токен: запись без разрешения", entry, http.MethodPost, "", readToken, http.StatusForbidden},
+		{"токен: другая область", manager, http.MethodGet, "", readToken, http.StatusForbidden},
+		{"токен: обработчик без области", reports, http.MethodGet, "", readToken, http.StatusForbidden},
+		{"недействительный токен", entry, http.MethodGet, "", "sib_unknown", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := m.Require(tt.access)(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+			r := httptest.NewRequest(tt.method, "/", nil)
+			if tt.session != "" {
+				r.AddCookie(&http.Cookie{Name: SessionCookie, Value: tt.session})
+			}
+			if tt.bearer != "" {
+				r.Header.Set("Authorization", "Bearer "+tt.bearer)
+			}
+			w := httptest.NewRecorder()
+			handler(w, r)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d (%s)", w.Code, tt.want, w.Body.String())
+			}
+		})
+	}
+}
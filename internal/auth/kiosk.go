@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Ошибки входа по PIN-коду
+var (
+	ErrInvalidPIN = errors.New("PIN must be 4 to 8 digits")
+	ErrPINLocked  = errors.New("PIN login is temporarily locked")
+	ErrPINRole    = errors.New("PIN login is only available for operators")
+)
+
+// PINLocked проверяет, что вход по PIN-коду заблокирован после неудачных попыток
+func (u User) PINLocked(now time.Time) bool {
+	return u.LockedUntil != nil && now.Before(*u.LockedUntil)
+}
+
+// KioskEmployee - сотрудник в списке выбора на планшете киоска
+type KioskEmployee struct {
+	ID       string `json:"id"`
+	FullName string `json:"fullName"`
+}
+
+// KioskEmployees возвращает операторов, которым задан PIN-код, в алфавитном порядке
+func (m *Manager) KioskEmployees() []KioskEmployee {
+	result := make([]KioskEmployee, 0)
+	for _, u := range m.users.All() {
+		if u.PINHash != "" && !u.Disabled && u.Role == RoleOperator {
+			result = append(result, KioskEmployee{ID: u.ID, FullName: u.FullName})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].FullName < result[j].FullName })
+	return result
+}
+
+// KioskIdle возвращает время бездействия, после которого завершается сессия киоска
+func (m *Manager) KioskIdle() time.Duration {
+	return m.opts.KioskIdle
+}
+
+// KioskLogin проверяет PIN-код оператора и создает сессию киоска
+// По PIN-коду входят только операторы: короткий PIN-код не должен открывать права мастера или администратора
+// После PINMaxAttempts неудачных попыток подряд вход по PIN-коду блокируется на PINLockout;
+// при блокировке возвращается ErrPINLocked и пользователь с временем окончания блокировки.
+// Попытка учитывается до сравнения PIN-кода, поэтому параллельные запросы не обходят блокировку
+func (m *Manager) KioskLogin(userID, pin string) (string, User, error) {
+	now := time.Now()
+	match := func(u User) bool { return u.ID == userID }
+
+	// Проверка блокировки и учет попытки выполняются одним изменением записи пользователя
+	u, found, err := m.users.Update(match, func(u *User) error {
+		if u.Disabled || u.PINHash == "" || u.Role != RoleOperator {
+			return ErrInvalidCredentials
+		}
+		if u.PINLocked(now) {
+			return ErrPINLocked
+		}
+		u.FailedPINs++
+		if m.opts.PINMaxAttempts > 0 && u.FailedPINs >= m.opts.PINMaxAttempts {
+			until := now.Add(m.opts.PINLockout)
+			u.LockedUntil = &until
+			u.FailedPINs = 0
+		}
+		return nil
+	})
+	if !found || errors.Is(err, ErrInvalidCredentials) {
+		bcrypt.CompareHashAndPassword(dummyHash, []byte(pin))
+		return "", User{}, ErrInvalidCredentials
+	}
+	if errors.Is(err, ErrPINLocked) {
+		return "", u, ErrPINLocked
+	}
+	if err != nil {
+		return "", User{}, err
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(u.PINHash), []byte(pin)); err != nil {
+		if u.PINLocked(now) {
+			return "", u, ErrPINLocked // Эта попытка была последней разрешенной
+		}
+		return "", User{}, ErrInvalidCredentials
+	}
+
+	// Успешный вход сбрасывает счетчик неудачных попыток, в том числе учтенную попытку
+	u, _, err = m.users.Update(match, func(u *User) error {
+		u.FailedPINs = 0
+		u.LockedUntil = nil
+		return nil
+	})
+	if err != nil {
+		return "", User{}, err
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return "", User{}, err
+	}
+	if err := m.startSession(token, u.ID, now.Add(m.opts.KioskIdle), true); err != nil {
+		return "", User{}, err
+	}
+	return token, u, nil
+}
+
+// hashPIN проверяет PIN-код (от 4 до 8 цифр) и возвращает его bcrypt-хеш
+func hashPIN(pin string) (string, error) {
+	if len(pin) < 4 || len(pin) > 8 {
+		return "", ErrInvalidPIN
+	}
+	for _, c := range pin {
+		if c < '0' || c > '9' {
+			return "", ErrInvalidPIN
+		}
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(pin), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash PIN: %v", err)
+	}
+	return string(hash), nil
+}
@@ -0,0 +1,143 @@
+package auth
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestManager создает менеджер с данными во временном каталоге
+func newTestManager(t *testing.T, opts Options) *Manager {
+	t.Helper()
+	m, err := NewManager(t.TempDir(), opts)
+	if err != nil {
+		t.Fatalf("NewManager: %v", err)
+	}
+	return m
+}
+
+func TestPINLocked(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	past, future := now.Add(-time.Minute), now.Add(time.Minute)
+
+	tests := []struct {
+		name  string
+		until *time.Time
+		want  bool
+	}{
+		{"не заблокирован", nil, false},
+		{"блокировка истекла", &past, false},
+		{"блокировка истекает сейчас", &now, false},
+		{"заблокирован", &future, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (User{LockedUntil: tt.until}).PINLocked(now); got != tt.want {
+				t.Errorf("PINLocked() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKioskLogin(t *testing.T) {
+	m := newTestManager(t, Options{KioskIdle: time.Minute, PINMaxAttempts: 3, PINLockout: time.Hour})
+	operator, err := m.CreateUser("ivanov", "Иванов И. И.", RoleOperator, "", "1234")
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	foreman, err := m.CreateUser("petrov", "Петров П. П.", RoleForeman, "password123", "")
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		userID  string
+		pin     string
+		wantErr error
+	}{
+		{"верный PIN-код", operator.ID, "1234", nil},
+		{"неверный PIN-код", operator.ID, "0000", ErrInvalidCredentials},
+		{"неизвестный сотрудник", "unknown", "1234", ErrInvalidCredentials},
+		{"сотрудник без PIN-кода", foreman.ID, "1234", ErrInvalidCredentials},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, _, err := m.KioskLogin(tt.userID, tt.pin)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("KioskLogin() error = %v, want %v", err, tt.wantErr)
+			}
+			if (token != "") != (tt.wantErr == nil) {
+				t.Errorf("KioskLogin() token = %q, want token only on success", token)
+			}
+		})
+	}
+}
+
+func TestKioskLoginLockout(t *testing.T) {
+	m := newTestManager(t, Options{KioskIdle: time.Minute, PINMaxAttempts: 3, PINLockout: time.Hour})
+	u, err := m.CreateUser("ivanov", "Иванов И. И.", RoleOperator, "", "1234")
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+
+	// Успешный вход сбрасывает счетчик неудачных попыток
+	for _, pin := range []string{"0000", "0000", "1234"} {
+		m.KioskLogin(u.ID, pin)
+	}
+	if got, _ := m.users.Find(func(x User) bool { return x.ID == u.ID }); got.FailedPINs != 0 || got.LockedUntil != nil {
+		t.Fatalf("after successful login FailedPINs = %d, LockedUntil = %v, want reset", got.FailedPINs, got.LockedUntil)
+	}
+
+	// Третья неудачная попытка подряд блокирует вход, в том числе с верным PIN-кодом
+	wants := []error{ErrInvalidCredentials, ErrInvalidCredentials, ErrPINLocked, ErrPINLocked}
+	for i, want := range wants {
+		if _, _, err := m.KioskLogin(u.ID, "0000"); !errors.Is(err, want) {
+			t.Fatalf("attempt %d: error = %v, want %v", i+1, err, want)
+		}
+	}
+	if _, locked, err := m.KioskLogin(u.ID, "1234"); !errors.Is(err, ErrPINLocked) || locked.LockedUntil == nil {
+		t.Fatalf("correct PIN while locked: error = %v, lockedUntil = %v, want ErrPINLocked with time", err, locked.LockedUntil)
+	}
+}
+
+func TestKioskLoginParallelAttempts(t *testing.T) {
+	const attempts = 20
+	m := newTestManager(t, Options{KioskIdle: time.Minute, PINMaxAttempts: 3, PINLockout: time.Hour})
+	u, err := m.CreateUser("ivanov", "Иванов И. И.", RoleOperator, "", "1234")
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+
+	// Одновременные неверные попытки не должны обойти блокировку: ErrInvalidCredentials получают
+	// только попытки до последней разрешенной, остальные - ErrPINLocked
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	invalid, locked := 0, 0
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, _, err := m.KioskLogin(u.ID, "0000")
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case errors.Is(err, ErrInvalidCredentials):
+				invalid++
+			case errors.Is(err, ErrPINLocked):
+				locked++
+			default:
+				t.Errorf("KioskLogin() error = %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if invalid != 2 || locked != attempts-2 {
+		t.Errorf("invalid = %d, locked = %d, want 2 and %d", invalid, locked, attempts-2)
+	}
+	if got, _ := m.users.Find(func(x User) bool { return x.ID == u.ID }); !got.PINLocked(time.Now()) {
+		t.Error("user is not locked after parallel wrong PINs")
+	}
+}
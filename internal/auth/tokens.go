@@ -0,0 +1,217 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sergekovalev/siberia/internal/store"
+	"github.com/sergekovalev/siberia/internal/utils"
+)
+
+// Области доступа API-токенов; разрешение записывается как "область:действие", например production:write
+const (
+	ScopeProduction  = "production"  // Выпуск и доработка брака
+	ScopeTimesheet   = "timesheet"   // Табель
+	ScopeDowntime    = "downtime"    // Простои оборудования
+	ScopeTools       = "tools"       // Инструмент и его замены
+	ScopeMaintenance = "maintenance" // Заявки на ремонт
+	ScopeReference   = "reference"   // Справочники: операции, оборудование, план, заказы, квалификация
+	ScopeStock       = "stock"       // Склад материалов и готовой продукции
+	ScopeReports     = "reports"     // Отчеты и аналитика
+	ScopeApprovals   = "approvals"   // Утверждение записей операторов
+	ScopePeriods     = "periods"     // Закрытые периоды
+	ScopeKiosk       = "kiosk"       // Планшет киоска: список сотрудников для входа по PIN-коду
+)
+
+// RoleToken - роль, с которой выполняются запросы по API-токену; пользователю ее назначить нельзя
+const RoleToken = "token"
+
+// Действия разрешений API-токенов
+const (
+	ActionRead  = "read"  // GET-запросы
+	ActionWrite = "write" // Изменяющие запросы
+)
+
+// tokenPrefix - префикс API-токена, по которому он отличается от других секретов в логах и конфигурации
+const tokenPrefix = "sib_"
+
+// Ошибки API-токенов
+var (
+	ErrInvalidPermission = errors.New("invalid permission, expected scope:read or scope:write")
+	ErrDeviceExists      = errors.New("device already has an active token")
+)
+
+// ValidPermission проверяет, что разрешение API-токена известно
+func ValidPermission(p string) bool {
+	scope, action, ok := strings.Cut(p, ":")
+	if !ok || (action != ActionRead && action != ActionWrite) {
+		return false
+	}
+	switch scope {
+	case ScopeProduction, ScopeTimesheet, ScopeDowntime, ScopeTools, ScopeMaintenance,
+		ScopeReference, ScopeStock, ScopeReports, ScopeApprovals, ScopePeriods, ScopeKiosk:
+		return true
+	}
+	return false
+}
+
+// APIToken представляет токен для станков с ЧПУ и скриптов
+// Сам токен показывается один раз при создании; в файле хранится его хеш
+type APIToken struct {
+	ID          string     `json:"id"`                  // Идентификатор токена
+	Name        string     `json:"name"`                // Название, например "Стойка DMG 1"
+	Hint        string     `json:"hint"`                // Начало токена для опознания
+	TokenHash   string     `json:"tokenHash,omitempty"` // SHA-256 токена
+	Permissions []string   `json:"permissions"`         // Разрешения вида production:write
+	Device      string     `json:"device,omitempty"`    // Имя (CN) сертификата устройства, к которому привязан токен
+	CreatedBy   string     `json:"createdBy"`           // Пользователь, создавший токен
+	Created     time.Time  `json:"created"`             // Время создания
+	LastUsed    *time.Time `json:"lastUsed,omitempty"`  // Время последнего обращения
+	RevokedAt   *time.Time `json:"revokedAt,omitempty"` // Время отзыва
+}
+
+// Allows проверяет, что токен имеет разрешение permission
+func (t APIToken) Allows(permission string) bool {
+	for _, p := range t.Permissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// user возвращает пользователя, от имени которого выполняется запрос с токеном
+// У токена нет роли: доступ определяется только разрешениями токена
+func (t APIToken) user() User {
+	return User{ID: "token:" + t.ID, Username: "token:" + t.Name, FullName: t.Name, Role: RoleToken}
+}
+
+// openTokens загружает API-токены из каталога данных приложения
+func openTokens(dataDir string) (*store.Collection[APIToken], error) {
+	return store.Open[APIToken](filepath.Join(dataDir, "tokens.json"))
+}
+
+// Tokens возвращает API-токены без хешей
+func (m *Manager) Tokens() []APIToken {
+	tokens := m.tokens.All()
+	for i := range tokens {
+		tokens[i].TokenHash = ""
+	}
+	return tokens
+}
+
+// CreateToken создает API-токен с разрешениями permissions и возвращает его вместе с описанием
+// Токен, привязанный к устройству device (имя в сертификате), принимается только по соединению
+// с сертификатом этого устройства, а само устройство входит по сертификату и без токена
+func (m *Manager) CreateToken(name string, permissions []string, device, createdBy string) (string, APIToken, error) {
+	for _, p := range permissions {
+		if !ValidPermission(p) {
+			return "", APIToken{}, ErrInvalidPermission
+		}
+	}
+	device = strings.TrimSpace(device)
+	if device != "" {
+		if _, found := m.deviceToken(device); found {
+			return "", APIToken{}, ErrDeviceExists
+		}
+	}
+	secret, err := newToken()
+	if err != nil {
+		return "", APIToken{}, err
+	}
+	token := tokenPrefix + secret
+
+	t := APIToken{
+		ID:          utils.NewID(),
+		Name:        strings.TrimSpace(name),
+		Hint:        token[:len(tokenPrefix)+6],
+		TokenHash:   HashToken(token),
+		Permissions: permissions,
+		Device:      device,
+		CreatedBy:   createdBy,
+		Created:     time.Now(),
+	}
+	if err := m.tokens.Add(t); err != nil {
+		return "", APIToken{}, err
+	}
+	t.TokenHash = ""
+	return token, t, nil
+}
+
+// RevokeToken отзывает API-токен; второй результат равен false, если токен не найден
+func (m *Manager) RevokeToken(id string) (APIToken, bool, error) {
+	now := time.Now()
+	t, found, err := m.tokens.Update(func(t APIToken) bool { return t.ID == id }, func(t *APIToken) error {
+		if t.RevokedAt == nil {
+			t.RevokedAt = &now
+		}
+		return nil
+	})
+	t.TokenHash = ""
+	return t, found, err
+}
+
+// tokenFromRequest возвращает API-токен запроса: из заголовка Authorization: Bearer или, без заголовка,
+// по проверенному сертификату зарегистрированного устройства (mTLS).
+// Второй результат сообщает, что запрос предъявил токен или сертификат зарегистрированного устройства,
+// третий - что токен действителен. Время последнего обращения сохраняется не чаще раза в минуту
+func (m *Manager) tokenFromRequest(r *http.Request) (APIToken, bool, bool) {
+	device := deviceName(r)
+
+	var t APIToken
+	if token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		token = strings.TrimSpace(token)
+		if !strings.HasPrefix(token, tokenPrefix) {
+			return APIToken{}, true, false
+		}
+		hash := HashToken(token)
+		found := false
+		if t, found = m.tokens.Find(func(t APIToken) bool { return t.TokenHash == hash }); !found || t.RevokedAt != nil {
+			return APIToken{}, true, false
+		}
+		// Токен устройства без его сертификата (например, скопированный с устройства) не действует
+		if t.Device != "" && t.Device != device {
+			return APIToken{}, true, false
+		}
+	} else if device != "" {
+		found := false
+		if t, found = m.deviceToken(device); !found {
+			return APIToken{}, false, false
+		}
+	} else {
+		return APIToken{}, false, false
+	}
+
+	now := time.Now()
+	if t.LastUsed == nil || now.Sub(*t.LastUsed) > time.Minute {
+		if updated, _, err := m.tokens.Update(func(x APIToken) bool { return x.ID == t.ID }, func(x *APIToken) error {
+			x.LastUsed = &now
+			return nil
+		}); err == nil {
+			t = updated
+		}
+	}
+	return t, true, true
+}
+
+// deviceToken возвращает действующий токен, привязанный к устройству device
+func (m *Manager) deviceToken(device string) (APIToken, bool) {
+	return m.tokens.Find(func(t APIToken) bool { return t.Device == device && t.RevokedAt == nil })
+}
+
+// deviceName возвращает имя (CN) проверенного сертификата клиента или пустую строку
+// Сертификат проверяется при установке соединения по центру из tls.clientCAFile
+func deviceName(r *http.Request) string {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return ""
+	}
+	return r.TLS.VerifiedChains[0][0].Subject.CommonName
+}
+
+// hasBearer проверяет, что запрос передает API-токен
+func hasBearer(r *http.Request) bool {
+	return strings.HasPrefix(r.Header.Get("Authorization"), "Bearer ")
+}
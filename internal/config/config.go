@@ -2,25 +2,185 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
 	"log"
+	"net/http"
+	"net/url"
 	"os"
+	"strings"
+	"time"
 )
 
 // Структура Config содержит параметры конфигурации приложения
 type Config struct {
-	Port            string `json:"port"`            // Порт для запуска HTTP-сервера
-	SpreadsheetID   string `json:"spreadsheetID"`   // ID таблицы Google Sheets
-	ProductionSheet string `json:"productionSheet"` // Название листа для данных о производстве
-	TimesheetSheet  string `json:"timesheetSheet"`  // Название листа для табеля учета рабочего времени
+	Port                  string `json:"port"`                  // Порт для запуска HTTP-сервера
+	SpreadsheetID         string `json:"spreadsheetID"`         // ID таблицы Google Sheets
+	ProductionSheet       string `json:"productionSheet"`       // Название листа для данных о производстве
+	TimesheetSheet        string `json:"timesheetSheet"`        // Название листа для табеля учета рабочего времени
+	OperationsSheet       string `json:"operationsSheet"`       // Название листа справочника операций с нормами времени
+	PlanSheet             string `json:"planSheet"`             // Название листа производственного плана
+	WorkOrdersSheet       string `json:"workOrdersSheet"`       // Название листа заказов (партий)
+	ReworkSheet           string `json:"reworkSheet"`           // Название листа записей о доработке брака
+	MachinesSheet         string `json:"machinesSheet"`         // Название листа реестра оборудования
+	DowntimeSheet         string `json:"downtimeSheet"`         // Название листа журнала простоев
+	ToolsSheet            string `json:"toolsSheet"`            // Название листа реестра режущего инструмента
+	ToolChangeSheet       string `json:"toolChangeSheet"`       // Название листа журнала замен инструмента
+	MaintenanceSheet      string `json:"maintenanceSheet"`      // Название листа-копии заявок на ремонт (пусто - без копии в таблице)
+	QualificationsSheet   string `json:"qualificationsSheet"`   // Название листа матрицы квалификации
+	MaterialsSheet        string `json:"materialsSheet"`        // Название листа материалов с остатками
+	BOMSheet              string `json:"bomSheet"`              // Название листа спецификаций (норм расхода материалов на деталь)
+	MaterialReceiptsSheet string `json:"materialReceiptsSheet"` // Название листа журнала поступлений материалов
+	StockSheet            string `json:"stockSheet"`            // Название листа движения готовой продукции
+
+	DataDir         string           `json:"dataDir"`         // Каталог служебных данных приложения (заявки и т.п.)
+	CredentialsFile string           `json:"credentialsFile"` // Файл ключа сервисного аккаунта Google (может быть зашифрован)
+	DowntimeReasons []DowntimeReason `json:"downtimeReasons"` // Коды причин простоя
+	DefectReasons   []DefectReason   `json:"defectReasons"`   // Коды причин брака
+	Shifts          []Shift          `json:"shifts"`          // Рабочие смены
+	ToolWarnPercent int              `json:"toolWarnPercent"` // Остаток стойкости (% от ресурса), при котором выдается предупреждение
+	LabourRate      float64          `json:"labourRate"`      // Стоимость нормо-часа, руб (для операций без стоимости труда)
+
+	QualificationMode     string `json:"qualificationMode"`     // Проверка квалификации при вводе выпуска: off, warn или reject
+	QualificationMinLevel int    `json:"qualificationMinLevel"` // Минимальный уровень квалификации для допуска к операции
+
+	ApprovalRequired  bool `json:"approvalRequired"`  // Записи операторов попадают в таблицу только после утверждения мастером
+	SessionTTLHours   int  `json:"sessionTTLHours"`   // Время жизни сессии пользователя, часов
+	KioskIdleSeconds  int  `json:"kioskIdleSeconds"`  // Бездействие на планшете киоска, после которого сотрудник выходит, секунд
+	PINMaxAttempts    int  `json:"pinMaxAttempts"`    // Неудачных попыток ввода PIN-кода до блокировки
+	PINLockoutMinutes int  `json:"pinLockoutMinutes"` // Длительность блокировки входа по PIN-коду, минут
+
+	CORS CORS `json:"cors"` // Политика междоменных запросов к API
+
+	MaxBodyBytes     int64 `json:"maxBodyBytes"`     // Максимальный размер тела запроса к API, байт
+	RateLimitPerIP   int   `json:"rateLimitPerIP"`   // Запросов к API в минуту с одного адреса; 0 - без ограничения
+	RateLimitPerUser int   `json:"rateLimitPerUser"` // Запросов к API в минуту от одного пользователя или токена; 0 - без ограничения
+	ProxyHops        int   `json:"proxyHops"`        // Число доверенных обратных прокси перед сервером; 0 - адрес клиента из соединения
+
+	TLS TLS `json:"tls"` // Параметры HTTPS; без сертификата сервер работает по HTTP
+}
+
+// TLS описывает сертификат сервера и проверку сертификатов устройств (mTLS)
+// Файлы сертификатов перечитываются при изменении без перезапуска сервера
+type TLS struct {
+	CertFile          string `json:"certFile"`          // Сертификат сервера (PEM), вместе с цепочкой
+	KeyFile           string `json:"keyFile"`           // Закрытый ключ сертификата (PEM)
+	MinVersion        string `json:"minVersion"`        // Минимальная версия TLS: 1.2 или 1.3
+	ClientCAFile      string `json:"clientCAFile"`      // Сертификаты центра, выпускающего сертификаты устройств (PEM); пусто - без mTLS
+	RequireClientCert bool   `json:"requireClientCert"` // Принимать только соединения с сертификатом устройства
+}
+
+// Enabled сообщает, что сервер должен работать по HTTPS
+func (t TLS) Enabled() bool {
+	return t.CertFile != ""
+}
+
+// CORS описывает, с каких сайтов браузер может обращаться к API (Cross-Origin Resource Sharing)
+// Запросы со страниц самого приложения разрешены всегда
+type CORS struct {
+	AllowedOrigins   []string `json:"allowedOrigins"`   // Разрешенные источники, например https://portal.example.ru; "*" - любой
+	AllowedMethods   []string `json:"allowedMethods"`   // Разрешенные методы
+	AllowedHeaders   []string `json:"allowedHeaders"`   // Разрешенные заголовки запроса
+	AllowCredentials bool     `json:"allowCredentials"` // Передавать cookie сессии в междоменных запросах
+	MaxAgeSeconds    int      `json:"maxAgeSeconds"`    // Время кэширования ответа на предварительный запрос, секунд
+}
+
+// Режимы проверки квалификации оператора
+const (
+	QualificationOff    = "off"    // Не проверять
+	QualificationWarn   = "warn"   // Записывать выпуск и предупреждать
+	QualificationReject = "reject" // Отклонять запись
+)
+
+// DowntimeReason описывает код причины простоя оборудования
+type DowntimeReason struct {
+	Code string `json:"code"` // Код причины, который сохраняется в журнале
+	Name string `json:"name"` // Название причины для отображения
+}
+
+// DefectReason описывает код причины брака
+type DefectReason struct {
+	Code string `json:"code"` // Код причины, который сохраняется в записи о производстве
+	Name string `json:"name"` // Название причины для отображения
+}
+
+// Shift описывает рабочую смену. Если окончание раньше начала, смена заканчивается на следующий день
+type Shift struct {
+	Name  string `json:"name"`  // Название смены
+	Start string `json:"start"` // Время начала (HH:MM)
+	End   string `json:"end"`   // Время окончания (HH:MM)
 }
 
 // LoadConfig загружает конфигурацию из файла config.json и переменных окружения
 func LoadConfig() Config {
 	// Устанавливаем значения по умолчанию
 	cfg := Config{
-		Port:            "8080",   // Порт по умолчанию
-		ProductionSheet: "Выпуск", // Название листа для производства по умолчанию
-		TimesheetSheet:  "Табель", // Название листа для табеля по умолчанию
+		Port:                  "8080",                   // Порт по умолчанию
+		ProductionSheet:       "Выпуск",                 // Название листа для производства по умолчанию
+		TimesheetSheet:        "Табель",                 // Название листа для табеля по умолчанию
+		OperationsSheet:       "Нормы",                  // Название листа справочника операций по умолчанию
+		PlanSheet:             "План",                   // Название листа плана по умолчанию
+		WorkOrdersSheet:       "Партии",                 // Название листа заказов по умолчанию
+		ReworkSheet:           "Доработка",              // Название листа доработки по умолчанию
+		MachinesSheet:         "Оборудование",           // Название листа оборудования по умолчанию
+		DowntimeSheet:         "Простои",                // Название листа простоев по умолчанию
+		ToolsSheet:            "Инструмент",             // Название листа инструмента по умолчанию
+		ToolChangeSheet:       "Замены инструмента",     // Название листа замен инструмента по умолчанию
+		MaterialsSheet:        "Материалы",              // Название листа материалов по умолчанию
+		BOMSheet:              "Спецификации",           // Название листа спецификаций по умолчанию
+		MaterialReceiptsSheet: "Поступления материалов", // Название листа поступлений материалов по умолчанию
+		StockSheet:            "Склад ГП",               // Название листа движения готовой продукции по умолчанию
+		DataDir:               "data",                   // Каталог служебных данных по умолчанию
+
+		QualificationsSheet:   "Квалификация",    // Название листа матрицы квалификации по умолчанию
+		QualificationMode:     QualificationWarn, // По умолчанию только предупреждаем о работе без допуска
+		QualificationMinLevel: 1,                 // Достаточно любого уровня допуска
+
+		ToolWarnPercent: 10, // Предупреждать, когда осталось 10% стойкости инструмента
+		SessionTTLHours: 12, // Сессия действует одну смену
+
+		ApprovalRequired: true, // Записи операторов проверяет мастер
+
+		KioskIdleSeconds:  120, // Выход с планшета через 2 минуты бездействия
+		PINMaxAttempts:    5,   // Пять неверных PIN-кодов подряд блокируют вход
+		PINLockoutMinutes: 15,  // на 15 минут
+
+		MaxBodyBytes:     64 << 10, // Записи и справочники умещаются в 64 КБ
+		RateLimitPerIP:   600,      // 10 запросов в секунду с одного адреса
+		RateLimitPerUser: 300,      // и 5 в секунду от одного пользователя
+
+		// Междоменные запросы по умолчанию запрещены: API доступно только страницам самого приложения
+		CORS: CORS{
+			AllowedMethods: []string{http.MethodGet, http.MethodHead, http.MethodPost},
+			AllowedHeaders: []string{"Content-Type", "Authorization"},
+			MaxAgeSeconds:  600,
+		},
+
+		TLS: TLS{MinVersion: "1.2"}, // TLS 1.0 и 1.1 устарели
+
+		// Причины простоя по умолчанию
+		DowntimeReasons: []DowntimeReason{
+			{Code: "tooling", Name: "Смена инструмента"},
+			{Code: "setup", Name: "Наладка"},
+			{Code: "breakdown", Name: "Поломка"},
+			{Code: "material", Name: "Нет материала"},
+			{Code: "other", Name: "Другое"},
+		},
+
+		// Причины брака по умолчанию
+		DefectReasons: []DefectReason{
+			{Code: "dimension", Name: "Размер вне допуска"},
+			{Code: "surface", Name: "Дефект поверхности"},
+			{Code: "material", Name: "Дефект заготовки"},
+			{Code: "setup", Name: "Ошибка наладки"},
+			{Code: "tooling", Name: "Износ или поломка инструмента"},
+			{Code: "other", Name: "Другое"},
+		},
+
+		// Две смены по 12 часов по умолчанию
+		Shifts: []Shift{
+			{Name: "Дневная", Start: "08:00", End: "20:00"},
+			{Name: "Ночная", Start: "20:00", End: "08:00"},
+		},
 	}
 
 	// Пытаемся открыть файл config.json
@@ -37,11 +197,140 @@ func LoadConfig() Config {
 		cfg.SpreadsheetID = envID // Если переменная задана, используем её значение
 	}
 
+	// Разрешенные источники междоменных запросов можно задать списком через запятую
+	if origins := os.Getenv("CORS_ALLOWED_ORIGINS"); origins != "" {
+		cfg.CORS.AllowedOrigins = strings.Split(origins, ",")
+	}
+
+	// Путь к ключу сервисного аккаунта можно задать переменной окружения
+	if path := os.Getenv("GOOGLE_CREDENTIALS_PATH"); path != "" {
+		cfg.CredentialsFile = path
+	}
+
 	// Если SpreadsheetID не задан, завершаем выполнение программы с ошибкой
 	if cfg.SpreadsheetID == "" {
 		log.Fatal("Необходимо указать SpreadsheetID")
 	}
 
+	// Проверяем описание смен
+	for _, shift := range cfg.Shifts {
+		if _, err := ParseClock(shift.Start); err != nil {
+			log.Fatalf("Некорректное время начала смены %q: %v", shift.Name, err)
+		}
+		if _, err := ParseClock(shift.End); err != nil {
+			log.Fatalf("Некорректное время окончания смены %q: %v", shift.Name, err)
+		}
+	}
+
+	// Проверяем режим проверки квалификации
+	switch cfg.QualificationMode {
+	case QualificationOff, QualificationWarn, QualificationReject:
+	default:
+		log.Fatalf("Некорректный режим проверки квалификации %q: ожидается off, warn или reject", cfg.QualificationMode)
+	}
+
+	// Проверяем время жизни сессии
+	if cfg.SessionTTLHours <= 0 {
+		log.Fatalf("Некорректное время жизни сессии %d: ожидается положительное число часов", cfg.SessionTTLHours)
+	}
+	if cfg.KioskIdleSeconds <= 0 || cfg.PINMaxAttempts <= 0 || cfg.PINLockoutMinutes < 0 {
+		log.Fatalf("Некорректные параметры режима киоска: kioskIdleSeconds и pinMaxAttempts должны быть положительными")
+	}
+
+	// Проверяем ограничения запросов
+	if cfg.MaxBodyBytes <= 0 || cfg.RateLimitPerIP < 0 || cfg.RateLimitPerUser < 0 || cfg.ProxyHops < 0 {
+		log.Fatalf("Некорректные ограничения запросов: maxBodyBytes должен быть положительным, rateLimitPerIP, rateLimitPerUser и proxyHops - неотрицательными")
+	}
+
+	// Сертификат и ключ можно задать переменными окружения
+	if certFile := os.Getenv("TLS_CERT_FILE"); certFile != "" {
+		cfg.TLS.CertFile = certFile
+	}
+	if keyFile := os.Getenv("TLS_KEY_FILE"); keyFile != "" {
+		cfg.TLS.KeyFile = keyFile
+	}
+
+	// Проверяем параметры HTTPS
+	if (cfg.TLS.CertFile == "") != (cfg.TLS.KeyFile == "") {
+		log.Fatalf("Для HTTPS необходимо указать и сертификат (tls.certFile), и ключ (tls.keyFile)")
+	}
+	if cfg.TLS.MinVersion != "1.2" && cfg.TLS.MinVersion != "1.3" {
+		log.Fatalf("Некорректная минимальная версия TLS %q: ожидается 1.2 или 1.3", cfg.TLS.MinVersion)
+	}
+	if !cfg.TLS.Enabled() && cfg.TLS.ClientCAFile != "" {
+		log.Fatalf("Проверка сертификатов устройств (tls.clientCAFile) требует HTTPS")
+	}
+	if cfg.TLS.RequireClientCert && cfg.TLS.ClientCAFile == "" {
+		log.Fatalf("tls.requireClientCert требует tls.clientCAFile")
+	}
+
+	// Проверяем политику междоменных запросов
+	if err := cfg.CORS.normalize(); err != nil {
+		log.Fatalf("Некорректная политика CORS: %v", err)
+	}
+
 	// Возвращаем загруженную конфигурацию
 	return cfg
 }
+
+// ParseClock разбирает время суток в формате HH:MM и возвращает количество минут от полуночи
+func ParseClock(s string) (int, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("expected HH:MM")
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// normalize приводит источники и методы политики CORS к каноническому виду и проверяет их
+// Браузер не передает cookie при разрешении любого источника, поэтому "*" несовместим с allowCredentials
+func (c *CORS) normalize() error {
+	for i, origin := range c.AllowedOrigins {
+		origin = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(origin)), "/")
+		if origin == "*" {
+			if c.AllowCredentials {
+				return fmt.Errorf("allowedOrigins \"*\" cannot be used with allowCredentials")
+			}
+		} else if u, err := url.Parse(origin); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.Path != "" {
+			return fmt.Errorf("invalid origin %q, expected scheme://host[:port]", origin)
+		}
+		c.AllowedOrigins[i] = origin
+	}
+	for i, method := range c.AllowedMethods {
+		c.AllowedMethods[i] = strings.ToUpper(strings.TrimSpace(method))
+	}
+	if c.MaxAgeSeconds < 0 {
+		return fmt.Errorf("maxAgeSeconds must not be negative")
+	}
+	return nil
+}
+
+// ValidShift проверяет, что смена с таким названием есть в конфигурации
+func (c Config) ValidShift(name string) bool {
+	for _, shift := range c.Shifts {
+		if shift.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidDowntimeReason проверяет, что код причины простоя есть в конфигурации
+func (c Config) ValidDowntimeReason(code string) bool {
+	for _, reason := range c.DowntimeReasons {
+		if reason.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidDefectReason проверяет, что код причины брака есть в конфигурации
+func (c Config) ValidDefectReason(code string) bool {
+	for _, reason := range c.DefectReasons {
+		if reason.Code == code {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,196 @@
+package googleapi
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/sergekovalev/siberia/internal/config"
+)
+
+// encryptedPrefix отмечает учетные данные, зашифрованные ключом из GOOGLE_CREDENTIALS_KEY
+// За префиксом следует base64 от nonce и шифротекста AES-256-GCM
+const encryptedPrefix = "siberia-encrypted:v1:"
+
+// credentialSource выдает токены доступа к Google API по ключу сервисного аккаунта
+// Ключ из файла перечитывается, когда файл изменился: после ротации ключа новый токен
+// будет получен уже по новому ключу без перезапуска сервера
+type credentialSource struct {
+	ctx  context.Context
+	path string // Файл с ключом; пусто, если ключ передан в переменной окружения
+
+	mu    sync.Mutex
+	mtime time.Time          // Время изменения файла, из которого загружен текущий ключ
+	ts    oauth2.TokenSource // Источник токенов по текущему ключу
+}
+
+// newCredentialSource загружает учетные данные в порядке:
+// GOOGLE_CREDENTIALS_BASE64, файл из credentialsFile (или GOOGLE_CREDENTIALS_PATH),
+// GOOGLE_APPLICATION_CREDENTIALS, credentials.json в рабочем каталоге
+func newCredentialSource(ctx context.Context, cfg config.Config) (*credentialSource, error) {
+	s := &credentialSource{ctx: ctx}
+
+	// Проверяем наличие учетных данных в переменной окружения GOOGLE_CREDENTIALS_BASE64
+	if base64Data := os.Getenv("GOOGLE_CREDENTIALS_BASE64"); base64Data != "" {
+		// Декодируем учетные данные из base64
+		data, err := base64.StdEncoding.DecodeString(base64Data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode base64 credentials: %v", err) // Ошибка декодирования
+		}
+		if s.ts, err = tokenSource(ctx, data); err != nil {
+			return nil, err
+		}
+		log.Println("Using credentials from GOOGLE_CREDENTIALS_BASE64") // Используем учетные данные из переменной окружения
+		return s, nil
+	}
+
+	// Явно указанный файл должен существовать; credentials.json - только запасной вариант
+	switch {
+	case cfg.CredentialsFile != "":
+		s.path = cfg.CredentialsFile
+	case os.Getenv("GOOGLE_APPLICATION_CREDENTIALS") != "":
+		s.path = os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")
+	default:
+		if _, err := os.Stat("credentials.json"); err != nil {
+			// Если учетные данные не найдены, возвращаем ошибку
+			return nil, fmt.Errorf("no credentials provided")
+		}
+		s.path = "credentials.json"
+	}
+
+	if err := s.reload(); err != nil {
+		return nil, err
+	}
+	log.Printf("Using credentials from %s", s.path) // Используем учетные данные из файла
+	return s, nil
+}
+
+// Token возвращает новый токен доступа; перед запросом токена проверяется, не заменен ли файл ключа
+// Вызывается, когда истек предыдущий токен (токены кэширует клиент oauth2)
+func (s *credentialSource) Token() (*oauth2.Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.path != "" {
+		if err := s.reload(); err != nil {
+			// Недописанный или поврежденный файл не должен останавливать работу: используем прежний ключ
+			log.Printf("Error reloading credentials from %s, keeping previous key: %v", s.path, err)
+		}
+	}
+	return s.ts.Token()
+}
+
+// reload перечитывает файл ключа, если он изменился с последней загрузки
+func (s *credentialSource) reload() error {
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return err
+	}
+	if s.ts != nil && info.ModTime().Equal(s.mtime) {
+		return nil
+	}
+
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return err
+	}
+	ts, err := tokenSource(s.ctx, data)
+	if err != nil {
+		return err
+	}
+	if s.ts != nil {
+		log.Printf("Credentials reloaded from %s", s.path)
+	}
+	s.ts, s.mtime = ts, info.ModTime()
+	return nil
+}
+
+// tokenSource расшифровывает учетные данные при необходимости и создает по ним источник токенов
+func tokenSource(ctx context.Context, data []byte) (oauth2.TokenSource, error) {
+	data, err := decryptCredentials(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// Создаем конфигурацию JWT из учетных данных
+	conf, err := google.JWTConfigFromJSON(data, sheets.SpreadsheetsScope)
+	if err != nil {
+		return nil, fmt.Errorf("invalid credentials: %v", err) // Ошибка в учетных данных
+	}
+	return conf.TokenSource(ctx), nil
+}
+
+// decryptCredentials расшифровывает учетные данные с префиксом encryptedPrefix ключом из GOOGLE_CREDENTIALS_KEY
+// Незашифрованные данные возвращаются без изменений
+func decryptCredentials(data []byte) ([]byte, error) {
+	encoded, ok := bytes.CutPrefix(bytes.TrimSpace(data), []byte(encryptedPrefix))
+	if !ok {
+		return data, nil
+	}
+	gcm, err := credentialsCipher()
+	if err != nil {
+		return nil, err
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(string(encoded))
+	if err != nil || len(sealed) < gcm.NonceSize() {
+		return nil, fmt.Errorf("malformed encrypted credentials")
+	}
+	plain, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt credentials, check GOOGLE_CREDENTIALS_KEY")
+	}
+	return plain, nil
+}
+
+// EncryptCredentials шифрует учетные данные ключом из GOOGLE_CREDENTIALS_KEY для хранения на диске
+func EncryptCredentials(data []byte) ([]byte, error) {
+	gcm, err := credentialsCipher()
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	sealed := gcm.Seal(nonce, nonce, data, nil)
+	return []byte(encryptedPrefix + base64.StdEncoding.EncodeToString(sealed) + "\n"), nil
+}
+
+// NewCredentialsKey создает случайный ключ шифрования учетных данных для GOOGLE_CREDENTIALS_KEY
+func NewCredentialsKey() (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// credentialsCipher создает шифр AES-256-GCM по ключу из GOOGLE_CREDENTIALS_KEY (32 байта в base64)
+func credentialsCipher() (cipher.AEAD, error) {
+	encoded := os.Getenv("GOOGLE_CREDENTIALS_KEY")
+	if encoded == "" {
+		return nil, fmt.Errorf("GOOGLE_CREDENTIALS_KEY is not set")
+	}
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(key) != 32 {
+		return nil, fmt.Errorf("GOOGLE_CREDENTIALS_KEY must be 32 bytes encoded in base64")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -2,12 +2,10 @@ package googleapi
 
 import (
 	"context"
-	"encoding/base64"
 	"fmt"
 	"log"
-	"os"
 
-	"golang.org/x/oauth2/google"
+	"golang.org/x/oauth2"
 	"google.golang.org/api/option"
 	"google.golang.org/api/sheets/v4"
 
@@ -16,21 +14,15 @@ import (
 
 // InitSheetsService инициализирует сервис Google Sheets с использованием учетных данных
 func InitSheetsService(cfg config.Config) (*sheets.Service, error) {
-	// Загружаем учетные данные
-	creds, err := loadCredentials()
+	// Загружаем учетные данные; ключ из файла перечитывается после ротации без перезапуска
+	ctx := context.Background()
+	source, err := newCredentialSource(ctx, cfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load credentials: %v", err) // Ошибка загрузки учетных данных
 	}
 
-	// Создаем конфигурацию JWT из учетных данных
-	conf, err := google.JWTConfigFromJSON(creds, sheets.SpreadsheetsScope)
-	if err != nil {
-		return nil, fmt.Errorf("invalid credentials: %v", err) // Ошибка в учетных данных
-	}
-
-	// Создаем контекст и инициализируем сервис Google Sheets
-	ctx := context.Background()
-	sheetsService, err := sheets.NewService(ctx, option.WithHTTPClient(conf.Client(ctx)))
+	// Инициализируем сервис Google Sheets
+	sheetsService, err := sheets.NewService(ctx, option.WithHTTPClient(oauth2.NewClient(ctx, source)))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create sheets service: %v", err) // Ошибка создания сервиса
 	}
@@ -48,26 +40,3 @@ func VerifyAccess(service *sheets.Service, spreadsheetID string) error {
 	log.Println("Spreadsheet access verified successfully") // Доступ успешно проверен
 	return nil
 }
-
-// loadCredentials загружает учетные данные для доступа к Google API
-func loadCredentials() ([]byte, error) {
-	// Проверяем наличие учетных данных в переменной окружения GOOGLE_CREDENTIALS_BASE64
-	if base64Data := os.Getenv("GOOGLE_CREDENTIALS_BASE64"); base64Data != "" {
-		// Декодируем учетные данные из base64
-		data, err := base64.StdEncoding.DecodeString(base64Data)
-		if err != nil {
-			return nil, fmt.Errorf("failed to decode base64 credentials: %v", err) // Ошибка декодирования
-		}
-		log.Println("Using credentials from GOOGLE_CREDENTIALS_BASE64") // Используем учетные данные из переменной окружения
-		return data, nil
-	}
-
-	// Если переменной окружения нет, пытаемся загрузить учетные данные из файла credentials.json
-	if data, err := os.ReadFile("credentials.json"); err == nil {
-		log.Println("Using credentials from credentials.json") // Используем учетные данные из файла
-		return data, nil
-	}
-
-	// Если учетные данные не найдены, возвращаем ошибку
-	return nil, fmt.Errorf("no credentials provided")
-}
@@ -0,0 +1,252 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/sergekovalev/siberia/internal/auth"
+	"github.com/sergekovalev/siberia/internal/config"
+	"github.com/sergekovalev/siberia/internal/models"
+	"github.com/sergekovalev/siberia/internal/store"
+	"github.com/sergekovalev/siberia/internal/utils"
+)
+
+// Действия мастера над записью на утверждении
+const (
+	reviewApprove = "approve" // Утвердить как есть
+	reviewReject  = "reject"  // Отклонить с указанием причины
+	reviewCorrect = "correct" // Исправить данные и утвердить
+)
+
+// reviewMu сериализует решения по записям, чтобы одна запись не попала в таблицу дважды
+var reviewMu sync.Mutex
+
+// needsApproval проверяет, что запись, внесенная в запросе, должна пройти утверждение мастером
+// Утверждения требуют записи операторов и записи, внесенные на планшете киоска.
+// Записи по API-токену утверждаются всегда: токен не привязан к сотруднику, и ФИО в записи
+// указывает сам скрипт, поэтому попасть в таблицу от имени сотрудника без проверки мастером она не может
+func needsApproval(r *http.Request, cfg config.Config) bool {
+	u, ok := auth.UserFromContext(r.Context())
+	if !ok {
+		return false
+	}
+	if u.Role == auth.RoleToken {
+		return true
+	}
+	return cfg.ApprovalRequired && (u.Role == auth.RoleOperator || auth.IsKiosk(r.Context()))
+}
+
+// submit сохраняет запись пользователя, выполнившего запрос, как ожидающую утверждения
+func submit(r *http.Request, submissions *store.Collection[models.Submission], s models.Submission) (models.Submission, error) {
+	u, _ := auth.UserFromContext(r.Context())
+	s.ID = utils.NewID()
+	s.Status = models.SubmissionPending
+	s.SubmittedBy = u.ID
+	s.SubmittedAt = time.Now()
+	if s.Production != nil {
+		s.FullName = strings.TrimSpace(s.Production.FullName)
+	} else if s.Timesheet != nil {
+		s.FullName = strings.TrimSpace(s.Timesheet.FullName)
+	}
+	if err := submissions.Add(s); err != nil {
+		return s, err
+	}
+	log.Printf("%s submission %s from %s is pending approval", s.Kind, s.ID, s.FullName)
+	return s, nil
+}
+
+// ApprovalsHandler возвращает записи на утверждении для мастера
+// Параметры: status (по умолчанию pending), kind (production, timesheet), employee; старые записи - первыми
+func ApprovalsHandler(submissions *store.Collection[models.Submission]) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		query := r.URL.Query()
+		status := query.Get("status")
+		if status == "" {
+			status = models.SubmissionPending
+		}
+		if !models.ValidSubmissionStatus(status) {
+			http.Error(w, "Invalid status, expected pending, approved or rejected", http.StatusBadRequest)
+			return
+		}
+		kind := query.Get("kind")
+		employee := query.Get("employee")
+
+		result := make([]models.Submission, 0)
+		for _, s := range submissions.All() {
+			if s.Status != status || (kind != "" && s.Kind != kind) || (employee != "" && s.FullName != employee) {
+				continue
+			}
+			result = append(result, s)
+		}
+		sort.SliceStable(result, func(i, j int) bool {
+			return result[i].SubmittedAt.Before(result[j].SubmittedAt)
+		})
+		writeJSON(w, http.StatusOK, result)
+	}
+}
+
+// ApprovalReviewHandler принимает решение мастера по записи:
+// POST {"id", "action": "approve" | "reject" | "correct", "reason", "production" | "timesheet"}
+// При утверждении запись проверяется заново и записывается в таблицу; для исправления передаются
+// исправленные данные записи. Отклонение требует причины, которую увидит оператор.
+// Запись с датой в закрытом периоде утвердить нельзя, только отклонить
+func ApprovalReviewHandler(srv *sheets.Service, cfg config.Config, submissions *store.Collection[models.Submission], periods *store.Collection[models.ClosedPeriod]) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req struct {
+			ID         string                 `json:"id"`
+			Action     string                 `json:"action"`
+			Reason     string                 `json:"reason"`
+			Production *models.ProductionData `json:"production"`
+			Timesheet  *models.TimesheetData  `json:"timesheet"`
+		}
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+		req.Reason = strings.TrimSpace(req.Reason)
+		if req.Action == reviewReject && req.Reason == "" {
+			http.Error(w, "Rejection reason is required", http.StatusBadRequest)
+			return
+		}
+
+		reviewMu.Lock()
+		defer reviewMu.Unlock()
+
+		s, found := submissions.Find(func(s models.Submission) bool { return s.ID == req.ID })
+		if !found {
+			http.Error(w, "Submission not found", http.StatusNotFound)
+			return
+		}
+		if s.Status != models.SubmissionPending {
+			http.Error(w, "Submission has already been reviewed", http.StatusConflict)
+			return
+		}
+
+		// Исправленные данные заменяют введенные; сотрудник записи не меняется
+		switch req.Action {
+		case reviewApprove, reviewReject:
+		case reviewCorrect:
+			switch {
+			case s.Kind == models.SubmissionProduction && req.Production != nil:
+				req.Production.FullName = s.Production.FullName
+				s.Production = req.Production
+			case s.Kind == models.SubmissionTimesheet && req.Timesheet != nil:
+				req.Timesheet.FullName = s.Timesheet.FullName
+				s.Timesheet = req.Timesheet
+			default:
+				http.Error(w, fmt.Sprintf("Corrected %s data is required", s.Kind), http.StatusBadRequest)
+				return
+			}
+			s.Corrected = true
+		default:
+			http.Error(w, "Invalid action, expected approve, reject or correct", http.StatusBadRequest)
+			return
+		}
+
+		// Утвержденная запись проверяется заново (справочники могли измениться) и записывается в таблицу;
+		// период не должен закрыться между проверкой и записью
+		warnings := make([]string, 0)
+		if req.Action != reviewReject {
+			periodMu.RLock()
+			defer periodMu.RUnlock()
+			var err error
+			switch s.Kind {
+			case models.SubmissionProduction:
+				var status int
+				var problem string
+				if warnings, status, problem = validateProduction(srv, cfg, s.Production); problem != "" {
+					http.Error(w, problem, status)
+					return
+				}
+				if status, problem = checkOpenPeriod(periods, s.Production.Date); problem != "" {
+					http.Error(w, problem, status)
+					return
+				}
+				var more []string
+				s.Row, more, err = recordProduction(srv, cfg, *s.Production)
+				warnings = append(warnings, more...)
+			case models.SubmissionTimesheet:
+				if problem := validateTimesheet(s.Timesheet); problem != "" {
+					http.Error(w, problem, http.StatusBadRequest)
+					return
+				}
+				if status, problem := checkOpenPeriod(periods, s.Timesheet.Date); problem != "" {
+					http.Error(w, problem, status)
+					return
+				}
+				err = models.AppendTimesheetData(srv, cfg.SpreadsheetID, *s.Timesheet)
+			}
+			if err != nil {
+				log.Printf("Error writing approved %s submission: %v", s.Kind, err)
+				http.Error(w, "Failed to write approved entry", http.StatusInternalServerError)
+				return
+			}
+		}
+
+		reviewer, _ := auth.UserFromContext(r.Context())
+		now := time.Now()
+		s.Status = models.SubmissionApproved
+		if req.Action == reviewReject {
+			s.Status = models.SubmissionRejected
+		}
+		s.ReviewedBy = reviewer.FullName
+		s.ReviewedAt = &now
+		s.Reason = req.Reason
+
+		updated, _, err := submissions.Update(func(x models.Submission) bool { return x.ID == s.ID }, func(x *models.Submission) error {
+			*x = s
+			return nil
+		})
+		if err != nil {
+			// Запись уже в таблице: повторное утверждение создало бы дубликат, поэтому сообщаем об ошибке сохранения
+			log.Printf("Error saving review of submission %s: %v", s.ID, err)
+			http.Error(w, "Entry written but review not saved", http.StatusInternalServerError)
+			return
+		}
+		log.Printf("Submission %s %s by %s", s.ID, s.Status, reviewer.Username)
+		writeJSON(w, http.StatusOK, map[string]interface{}{"submission": updated, "warnings": warnings})
+	}
+}
+
+// MySubmissionsHandler возвращает записи пользователя, выполнившего запрос, вместе с решениями мастера
+// Параметр status фильтрует записи; новые записи - первыми, не больше 50
+func MySubmissionsHandler(submissions *store.Collection[models.Submission]) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		u, _ := auth.UserFromContext(r.Context())
+		status := r.URL.Query().Get("status")
+		result := make([]models.Submission, 0)
+		for _, s := range submissions.All() {
+			if s.SubmittedBy == u.ID && (status == "" || s.Status == status) {
+				result = append(result, s)
+			}
+		}
+		sort.SliceStable(result, func(i, j int) bool {
+			return result[i].SubmittedAt.After(result[j].SubmittedAt)
+		})
+		if len(result) > 50 {
+			result = result[:50]
+		}
+		writeJSON(w, http.StatusOK, result)
+	}
+}
@@ -0,0 +1,290 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sergekovalev/siberia/internal/auth"
+)
+
+// LoginHandler выполняет вход пользователя: POST {"username", "password"}
+// При успешном входе токен сессии передается в cookie, в ответе - сведения о пользователе
+func LoginHandler(am *auth.Manager, ttl time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req struct {
+			Username string `json:"username"`
+			Password string `json:"password"`
+		}
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+
+		token, u, err := am.Login(req.Username, req.Password)
+		if errors.Is(err, auth.ErrInvalidCredentials) {
+			log.Printf("Failed login attempt for %q from %s", req.Username, r.RemoteAddr)
+			http.Error(w, "Invalid username or password", http.StatusUnauthorized)
+			return
+		}
+		if err != nil {
+			log.Printf("Error creating session: %v", err)
+			http.Error(w, "Failed to log in", http.StatusInternalServerError)
+			return
+		}
+
+		http.SetCookie(w, &http.Cookie{
+			Name:     auth.SessionCookie,
+			Value:    token,
+			Path:     "/",
+			MaxAge:   int(ttl.Seconds()),
+			HttpOnly: true,
+			Secure:   r.TLS != nil,
+			SameSite: http.SameSiteLaxMode,
+		})
+		log.Printf("User %s logged in", u.Username)
+		writeJSON(w, http.StatusOK, u.Info())
+	}
+}
+
+// LogoutHandler завершает сессию пользователя и удаляет cookie
+func LogoutHandler(am *auth.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		if cookie, err := r.Cookie(auth.SessionCookie); err == nil {
+			if err := am.Logout(cookie.Value); err != nil {
+				log.Printf("Error deleting session: %v", err)
+				http.Error(w, "Failed to log out", http.StatusInternalServerError)
+				return
+			}
+		}
+		http.SetCookie(w, &http.Cookie{Name: auth.SessionCookie, Value: "", Path: "/", MaxAge: -1, HttpOnly: true})
+		writeJSON(w, http.StatusOK, map[string]string{"status": "success"})
+	}
+}
+
+// MeHandler возвращает сведения о пользователе текущей сессии
+func MeHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	u, _ := auth.UserFromContext(r.Context())
+	writeJSON(w, http.StatusOK, u.Info())
+}
+
+// UsersHandler обрабатывает запросы к учетным записям пользователей
+// GET возвращает пользователей, POST создает пользователя: {"username", "fullName", "role", "password", "pin"}
+// Сотруднику, который работает только на планшете киоска, достаточно PIN-кода без пароля
+func UsersHandler(am *auth.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			users := am.Users()
+			result := make([]auth.UserInfo, 0, len(users))
+			for _, u := range users {
+				result = append(result, u.Info())
+			}
+			sort.Slice(result, func(i, j int) bool { return result[i].Username < result[j].Username })
+			writeJSON(w, http.StatusOK, result)
+
+		case http.MethodPost:
+			var req struct {
+				Username string `json:"username"`
+				FullName string `json:"fullName"`
+				Role     string `json:"role"`
+				Password string `json:"password"`
+				PIN      string `json:"pin"`
+			}
+			if !decodeJSON(w, r, &req) {
+				return
+			}
+
+			// Проверяем обязательные поля
+			if strings.TrimSpace(req.Username) == "" || strings.TrimSpace(req.FullName) == "" {
+				http.Error(w, "Username and full name are required", http.StatusBadRequest)
+				return
+			}
+			if !auth.ValidRole(req.Role) {
+				http.Error(w, "Invalid role, expected operator, foreman, accountant or admin", http.StatusBadRequest)
+				return
+			}
+
+			u, err := am.CreateUser(req.Username, req.FullName, req.Role, req.Password, req.PIN)
+			switch {
+			case errors.Is(err, auth.ErrUserExists):
+				http.Error(w, "User already exists", http.StatusConflict)
+				return
+			case errors.Is(err, auth.ErrWeakPassword), errors.Is(err, auth.ErrInvalidPIN), errors.Is(err, auth.ErrPINRole):
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			case err != nil:
+				log.Printf("Error saving user: %v", err)
+				http.Error(w, "Failed to save user", http.StatusInternalServerError)
+				return
+			}
+			log.Printf("User %s (%s) created", u.Username, u.Role)
+			writeJSON(w, http.StatusCreated, u.Info())
+
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// UserUpdateHandler изменяет учетную запись: POST {"id", "fullName", "role", "password", "pin", "disabled"}
+// Пустые поля не изменяются; смена пароля и блокировка завершают сессии пользователя,
+// новый PIN-код снимает блокировку входа по PIN-коду
+func UserUpdateHandler(am *auth.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req struct {
+			ID       string `json:"id"`
+			FullName string `json:"fullName"`
+			Role     string `json:"role"`
+			Password string `json:"password"`
+			PIN      string `json:"pin"`
+			Disabled *bool  `json:"disabled"`
+		}
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+		if req.ID == "" {
+			http.Error(w, "User id is required", http.StatusBadRequest)
+			return
+		}
+		if req.Role != "" && !auth.ValidRole(req.Role) {
+			http.Error(w, "Invalid role, expected operator, foreman, accountant or admin", http.StatusBadRequest)
+			return
+		}
+
+		// Администратор не может заблокировать себя или снять с себя роль администратора
+		if self, _ := auth.UserFromContext(r.Context()); self.ID == req.ID {
+			if (req.Disabled != nil && *req.Disabled) || (req.Role != "" && req.Role != auth.RoleAdmin) {
+				http.Error(w, "You cannot disable yourself or remove your own admin role", http.StatusBadRequest)
+				return
+			}
+		}
+
+		u, found, err := am.UpdateUser(req.ID, auth.UserChanges{
+			FullName: req.FullName,
+			Role:     req.Role,
+			Password: req.Password,
+			PIN:      req.PIN,
+			Disabled: req.Disabled,
+		})
+		switch {
+		case errors.Is(err, auth.ErrWeakPassword), errors.Is(err, auth.ErrInvalidPIN), errors.Is(err, auth.ErrPINRole):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		case err != nil:
+			log.Printf("Error updating user: %v", err)
+			http.Error(w, "Failed to update user", http.StatusInternalServerError)
+			return
+		case !found:
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		}
+		log.Printf("User %s updated", u.Username)
+		writeJSON(w, http.StatusOK, u.Info())
+	}
+}
+
+// KioskEmployeesHandler возвращает операторов с PIN-кодом для выбора на планшете киоска
+func KioskEmployeesHandler(am *auth.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		writeJSON(w, http.StatusOK, am.KioskEmployees())
+	}
+}
+
+// KioskLoginHandler выполняет вход сотрудника на планшете киоска: POST {"id", "pin"}
+// Cookie сессии живет до закрытия браузера, а сессия на сервере завершается после бездействия;
+// в ответе возвращается время бездействия в секундах (idleSeconds) для таймера на странице
+func KioskLoginHandler(am *auth.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req struct {
+			ID  string `json:"id"`
+			PIN string `json:"pin"`
+		}
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+
+		token, u, err := am.KioskLogin(req.ID, req.PIN)
+		switch {
+		case errors.Is(err, auth.ErrPINLocked):
+			log.Printf("PIN login locked for %s", u.Username)
+			writeJSON(w, http.StatusLocked, map[string]interface{}{
+				"message":     "Too many wrong PINs, login is locked",
+				"lockedUntil": u.LockedUntil,
+			})
+			return
+		case errors.Is(err, auth.ErrInvalidCredentials):
+			log.Printf("Failed PIN login for user %q from %s", req.ID, r.RemoteAddr)
+			http.Error(w, "Invalid PIN", http.StatusUnauthorized)
+			return
+		case err != nil:
+			log.Printf("Error creating kiosk session: %v", err)
+			http.Error(w, "Failed to log in", http.StatusInternalServerError)
+			return
+		}
+
+		http.SetCookie(w, &http.Cookie{
+			Name:     auth.SessionCookie,
+			Value:    token,
+			Path:     "/",
+			HttpOnly: true,
+			Secure:   r.TLS != nil,
+			SameSite: http.SameSiteLaxMode,
+		})
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"user":        u.Info(),
+			"idleSeconds": int(am.KioskIdle().Seconds()),
+		})
+	}
+}
+
+// sessionFullName возвращает ФИО сотрудника, от имени которого вносится запись
+// Операторы и все сотрудники, вошедшие на планшете киоска, вносят записи только от своего имени,
+// поэтому для них ФИО из запроса заменяется ФИО учетной записи. Для остальных ролей
+// (мастер вносит записи за сотрудников) возвращается пустая строка
+func sessionFullName(r *http.Request) string {
+	u, ok := auth.UserFromContext(r.Context())
+	if !ok || (u.Role != auth.RoleOperator && !auth.IsKiosk(r.Context())) {
+		return ""
+	}
+	return u.FullName
+}
+
+// authorName возвращает автора действия: вошедшего пользователя (или имя API-токена)
+// ФИО из запроса claimed используется, только если у пользователя нет ФИО
+func authorName(r *http.Request, claimed string) string {
+	if u, ok := auth.UserFromContext(r.Context()); ok && u.FullName != "" {
+		return u.FullName
+	}
+	return strings.TrimSpace(claimed)
+}
@@ -0,0 +1,303 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/sergekovalev/siberia/internal/config"
+	"github.com/sergekovalev/siberia/internal/models"
+	"github.com/sergekovalev/siberia/internal/reports"
+	"github.com/sergekovalev/siberia/internal/utils"
+)
+
+// DowntimeReasonsHandler возвращает настроенные коды причин простоя
+func DowntimeReasonsHandler(cfg config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		writeJSON(w, http.StatusOK, cfg.DowntimeReasons)
+	}
+}
+
+// DowntimeHandler возвращает простои, пересекающиеся с периодом from-to
+// Параметры: from, to (YYYY-MM-DD), machine - фильтр по станку, open=1 - только незавершенные простои
+func DowntimeHandler(srv *sheets.Service, cfg config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		from, to, _, err := parsePeriod(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		machine := r.URL.Query().Get("machine")
+		onlyOpen := r.URL.Query().Get("open") == "1"
+
+		events, err := models.ReadDowntime(srv, cfg)
+		if err != nil {
+			log.Printf("Error reading downtime: %v", err)
+			http.Error(w, "Failed to read downtime", http.StatusInternalServerError)
+			return
+		}
+
+		// Границы периода в местном времени: с начала дня from до конца дня to
+		periodStart := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.Local)
+		periodEnd := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.Local).AddDate(0, 0, 1)
+
+		result := make([]models.Downtime, 0, len(events))
+		for _, d := range events {
+			if machine != "" && d.Machine != machine {
+				continue
+			}
+			if onlyOpen && !d.Open() {
+				continue
+			}
+			// Незавершенные простои показываются всегда, завершенные - если пересекаются с периодом
+			if !d.Open() && (!d.End.After(periodStart) || !d.Start.Before(periodEnd)) {
+				continue
+			}
+			result = append(result, d)
+		}
+		writeJSON(w, http.StatusOK, result)
+	}
+}
+
+// downtimeMu сериализует начало и окончание простоев, чтобы у станка не появилось два незавершенных простоя
+var downtimeMu sync.Mutex
+
+// DowntimeStartHandler фиксирует начало простоя станка
+// Тело запроса: {"machine", "reason", "notes", "fullName", "start": "YYYY-MM-DD HH:MM" (по умолчанию - сейчас)}
+func DowntimeStartHandler(srv *sheets.Service, cfg config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req struct {
+			Machine  string `json:"machine"`
+			Reason   string `json:"reason"`
+			Notes    string `json:"notes"`
+			FullName string `json:"fullName"`
+			Start    string `json:"start"`
+		}
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+
+		// Оператор вносит записи только от своего имени
+		if name := sessionFullName(r); name != "" {
+			req.FullName = name
+		}
+
+		// Проверяем станок и причину простоя
+		req.Machine = strings.TrimSpace(req.Machine)
+		if req.Machine == "" {
+			http.Error(w, "Machine is required", http.StatusBadRequest)
+			return
+		}
+		if !cfg.ValidDowntimeReason(req.Reason) {
+			http.Error(w, "Unknown downtime reason", http.StatusBadRequest)
+			return
+		}
+		start, err := parseEventTime(req.Start)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		machine, err := models.FindMachine(srv, cfg, req.Machine)
+		if err != nil {
+			log.Printf("Error reading machines: %v", err)
+			http.Error(w, "Failed to process data", http.StatusInternalServerError)
+			return
+		}
+		if machine == nil {
+			http.Error(w, "Unknown machine", http.StatusBadRequest)
+			return
+		}
+
+		// У станка может быть только один незавершенный простой: проверка и запись выполняются под downtimeMu
+		downtimeMu.Lock()
+		defer downtimeMu.Unlock()
+
+		events, err := models.ReadDowntime(srv, cfg)
+		if err != nil {
+			log.Printf("Error reading downtime: %v", err)
+			http.Error(w, "Failed to process data", http.StatusInternalServerError)
+			return
+		}
+		for _, d := range events {
+			if d.Machine == req.Machine && d.Open() {
+				http.Error(w, "Machine already has an open downtime", http.StatusConflict)
+				return
+			}
+		}
+
+		d := models.Downtime{
+			ID:       utils.NewID(),
+			Machine:  req.Machine,
+			Reason:   req.Reason,
+			Start:    start,
+			Notes:    strings.TrimSpace(req.Notes),
+			FullName: strings.TrimSpace(req.FullName),
+		}
+		if err := models.AppendDowntime(srv, cfg, d); err != nil {
+			log.Printf("Error writing downtime: %v", err)
+			http.Error(w, "Failed to process data", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusCreated, map[string]string{"status": "success", "id": d.ID})
+	}
+}
+
+// DowntimeStopHandler фиксирует окончание простоя
+// Тело запроса: {"id"} или {"machine"} - незавершенный простой станка, "end": "YYYY-MM-DD HH:MM" (по умолчанию - сейчас)
+func DowntimeStopHandler(srv *sheets.Service, cfg config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req struct {
+			ID      string `json:"id"`
+			Machine string `json:"machine"`
+			End     string `json:"end"`
+		}
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+		if req.ID == "" && req.Machine == "" {
+			http.Error(w, "Downtime id or machine is required", http.StatusBadRequest)
+			return
+		}
+		end, err := parseEventTime(req.End)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// Поиск незавершенного простоя и его закрытие не должны чередоваться с началом простоя
+		downtimeMu.Lock()
+		defer downtimeMu.Unlock()
+
+		events, err := models.ReadDowntime(srv, cfg)
+		if err != nil {
+			log.Printf("Error reading downtime: %v", err)
+			http.Error(w, "Failed to process data", http.StatusInternalServerError)
+			return
+		}
+
+		// Ищем незавершенный простой по идентификатору или станку
+		var found *models.Downtime
+		for i := range events {
+			d := &events[i]
+			if d.Open() && ((req.ID != "" && d.ID == req.ID) || (req.ID == "" && d.Machine == req.Machine)) {
+				found = d
+				break
+			}
+		}
+		if found == nil {
+			http.Error(w, "Open downtime not found", http.StatusNotFound)
+			return
+		}
+		if end.Before(found.Start) {
+			http.Error(w, "Downtime end must not be before its start", http.StatusBadRequest)
+			return
+		}
+
+		found.End = &end
+		if err := models.UpdateDowntime(srv, cfg, *found); err != nil {
+			log.Printf("Error updating downtime: %v", err)
+			http.Error(w, "Failed to process data", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"status":  "success",
+			"id":      found.ID,
+			"minutes": int(end.Sub(found.Start).Minutes()),
+		})
+	}
+}
+
+// DowntimeReportHandler возвращает минуты простоя по станкам и причинам в каждой смене
+// Параметры: from, to (YYYY-MM-DD), machine - фильтр по станку, format=csv - таблица CSV вместо JSON
+func DowntimeReportHandler(srv *sheets.Service, cfg config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		from, to, _, err := parsePeriod(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		events, err := models.ReadDowntime(srv, cfg)
+		if err != nil {
+			log.Printf("Error reading downtime: %v", err)
+			http.Error(w, "Failed to read downtime", http.StatusInternalServerError)
+			return
+		}
+		if machine := r.URL.Query().Get("machine"); machine != "" {
+			filtered := events[:0]
+			for _, d := range events {
+				if d.Machine == machine {
+					filtered = append(filtered, d)
+				}
+			}
+			events = filtered
+		}
+
+		rows := reports.DowntimeByShift(events, cfg.Shifts, cfg.DowntimeReasons, from, to, time.Now())
+		if r.URL.Query().Get("format") != "csv" {
+			writeJSON(w, http.StatusOK, rows)
+			return
+		}
+
+		// Выгрузка в CSV для открытия в табличном редакторе
+		// Названия станков и причин вводят пользователи, поэтому они защищаются от выполнения как формулы
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=downtime_%s_%s.csv", from.Format("2006-01-02"), to.Format("2006-01-02")))
+		writer := csv.NewWriter(w)
+		writer.Write([]string{"Дата смены", "Смена", "Станок", "Причина", "Минуты", "Случаев"})
+		for _, row := range rows {
+			writer.Write([]string{
+				utils.SafeCellText(row.ShiftDate),
+				utils.SafeCellText(row.Shift),
+				utils.SafeCellText(row.Machine),
+				utils.SafeCellText(row.ReasonName),
+				fmt.Sprintf("%.0f", row.Minutes),
+				fmt.Sprintf("%d", row.Events),
+			})
+		}
+		writer.Flush()
+	}
+}
+
+// parseEventTime разбирает время события в формате YYYY-MM-DD HH:MM, пустое значение означает текущее время
+func parseEventTime(s string) (time.Time, error) {
+	if strings.TrimSpace(s) == "" {
+		return time.Now(), nil
+	}
+	t, err := utils.ParseDateTime(s)
+	if err != nil {
+		return t, fmt.Errorf("Invalid time format, expected YYYY-MM-DD HH:MM")
+	}
+	return t, nil
+}
@@ -1,24 +1,223 @@
 package handlers
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
 	"net/http"
+	"os"
+	"path/filepath"
+	"time"
 
 	"google.golang.org/api/sheets/v4"
 
+	"github.com/sergekovalev/siberia/internal/auth"
 	"github.com/sergekovalev/siberia/internal/config"
+	"github.com/sergekovalev/siberia/internal/models"
+	"github.com/sergekovalev/siberia/internal/store"
 	"github.com/sergekovalev/siberia/internal/utils"
 )
 
+// Группы ролей для разграничения доступа к обработчикам
+var (
+	allRoles       = []string{auth.RoleOperator, auth.RoleForeman, auth.RoleAccountant, auth.RoleAdmin}
+	entryRoles     = []string{auth.RoleOperator, auth.RoleForeman, auth.RoleAdmin}   // Ввод данных с участка
+	managerRoles   = []string{auth.RoleForeman, auth.RoleAdmin}                      // Ведение справочников и плана
+	reportingRoles = []string{auth.RoleForeman, auth.RoleAccountant, auth.RoleAdmin} // Отчеты и склад
+)
+
+// Права доступа к обработчикам: Read - для GET-запросов, Write - для изменяющих запросов
+var (
+	reading   = auth.Access{Read: allRoles}                                                  // Справочные данные только для чтения
+	reference = auth.Access{Read: allRoles, Write: managerRoles}                             // Справочники, план, заказы
+	entry     = auth.Access{Read: allRoles, Write: entryRoles}                               // Ввод выпуска, простоев, заявок
+	timesheet = auth.Access{Write: allRoles}                                                 // Табель (оператор - только за себя)
+	reporting = auth.Access{Read: reportingRoles}                                            // Отчеты
+	review    = auth.Access{Read: managerRoles, Write: managerRoles}                         // Утверждение записей операторов
+	warehouse = auth.Access{Read: reportingRoles, Write: reportingRoles}                     // Склад материалов и готовой продукции
+	admin     = auth.Access{Read: []string{auth.RoleAdmin}, Write: []string{auth.RoleAdmin}} // Учетные записи
+)
+
 // InitHandlers инициализирует обработчики HTTP-запросов
-func InitHandlers(srv *sheets.Service, cfg config.Config) {
+// Возвращает ошибку, если не удалось загрузить служебные данные приложения
+func InitHandlers(srv *sheets.Service, cfg config.Config) error {
+	// Заявки на ремонт хранятся в каталоге данных приложения
+	tickets, err := store.Open[models.MaintenanceTicket](filepath.Join(cfg.DataDir, "maintenance.json"))
+	if err != nil {
+		return fmt.Errorf("failed to load maintenance tickets: %v", err)
+	}
+
+	// Записи операторов, ожидающие утверждения мастером
+	submissions, err := store.Open[models.Submission](filepath.Join(cfg.DataDir, "submissions.json"))
+	if err != nil {
+		return fmt.Errorf("failed to load submissions: %v", err)
+	}
+
+	// Закрытые периоды и журнал аудита административных действий
+	periods, err := store.Open[models.ClosedPeriod](filepath.Join(cfg.DataDir, "periods.json"))
+	if err != nil {
+		return fmt.Errorf("failed to load closed periods: %v", err)
+	}
+	audit, err := store.Open[models.AuditEntry](filepath.Join(cfg.DataDir, "audit.json"))
+	if err != nil {
+		return fmt.Errorf("failed to load audit log: %v", err)
+	}
+
+	// Пользователи и сессии; все обработчики, кроме входа и проверки состояния, требуют входа в систему
+	ttl := time.Duration(cfg.SessionTTLHours) * time.Hour
+	am, err := auth.NewManager(cfg.DataDir, auth.Options{
+		SessionTTL:     ttl,
+		KioskIdle:      time.Duration(cfg.KioskIdleSeconds) * time.Second,
+		PINMaxAttempts: cfg.PINMaxAttempts,
+		PINLockout:     time.Duration(cfg.PINLockoutMinutes) * time.Minute,
+		RateLimit:      cfg.RateLimitPerUser,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to load users: %v", err)
+	}
+	if err := bootstrapAdmin(am); err != nil {
+		return fmt.Errorf("failed to create administrator: %v", err)
+	}
+	require := am.Require
+
+	// Общие промежуточные обработчики API: ограничение запросов с одного адреса (в том числе попыток входа),
+	// политика CORS и ограничение размера тела запроса
+	ipLimit := utils.RateLimit(utils.NewRateLimiter(cfg.RateLimitPerIP), cfg.ProxyHops)
+	cors := utils.CORS(cfg.CORS)
+	limitBody := utils.LimitBody(cfg.MaxBodyBytes)
+	api := func(next http.HandlerFunc) http.HandlerFunc { return ipLimit(cors(limitBody(next))) }
+
+	// Вход и выход пользователей, учетные записи
+	http.HandleFunc("/api/auth/login", api(LoginHandler(am, ttl)))
+	http.HandleFunc("/api/auth/logout", api(LogoutHandler(am)))
+	http.HandleFunc("/api/auth/me", api(require(reading)(MeHandler)))
+	http.HandleFunc("/api/users", api(require(admin)(UsersHandler(am))))
+	http.HandleFunc("/api/users/update", api(require(admin)(UserUpdateHandler(am))))
+
+	// API-токены для станков с ЧПУ и скриптов
+	http.HandleFunc("/api/tokens", api(require(admin)(TokensHandler(am, audit))))
+	http.HandleFunc("/api/tokens/revoke", api(require(admin)(TokenRevokeHandler(am, audit))))
+
+	// Вход по PIN-коду на общем планшете (режим киоска)
+	// Список сотрудников получает только зарегистрированный планшет (токен с разрешением kiosk:read,
+	// обычно привязанный к сертификату устройства) и администратор
+	http.HandleFunc("/api/kiosk/employees", api(require(admin.For(auth.ScopeKiosk))(KioskEmployeesHandler(am))))
+	http.HandleFunc("/api/kiosk/login", api(KioskLoginHandler(am)))
+
 	// Обработчик для отправки данных о производстве
-	// Включает CORS (разрешение междоменных запросов) через utils.EnableCORS
-	http.HandleFunc("/submit-production", utils.EnableCORS(ProductionHandler(srv, cfg)))
+	http.HandleFunc("/submit-production", api(require(entry.For(auth.ScopeProduction).ForKiosk())(ProductionHandler(srv, cfg, submissions, periods))))
 
 	// Обработчик для отправки данных табеля учета рабочего времени
-	// Также включает CORS
-	http.HandleFunc("/submit-timesheet", utils.EnableCORS(TimesheetHandler(srv, cfg)))
+	http.HandleFunc("/submit-timesheet", api(require(timesheet.For(auth.ScopeTimesheet).ForKiosk())(TimesheetHandler(srv, cfg, submissions, periods))))
+
+	// Утверждение записей операторов мастером и решения по записям для оператора
+	http.HandleFunc("/api/approvals", api(require(review.For(auth.ScopeApprovals))(ApprovalsHandler(submissions))))
+	http.HandleFunc("/api/approvals/review", api(require(review.For(auth.ScopeApprovals))(ApprovalReviewHandler(srv, cfg, submissions, periods))))
+	http.HandleFunc("/api/submissions/mine", api(require(reading.ForKioskRead())(MySubmissionsHandler(submissions))))
+
+	// Закрытие и открытие периодов, журнал аудита
+	http.HandleFunc("/api/periods", api(require(reading.For(auth.ScopePeriods))(PeriodsHandler(periods))))
+	http.HandleFunc("/api/periods/close", api(require(admin.For(auth.ScopePeriods))(PeriodCloseHandler(periods, audit))))
+	http.HandleFunc("/api/periods/reopen", api(require(admin.For(auth.ScopePeriods))(PeriodReopenHandler(periods, audit))))
+	http.HandleFunc("/api/audit", api(require(admin)(AuditHandler(audit))))
+
+	// Справочник операций с нормами времени
+	http.HandleFunc("/api/operations", api(require(reference.For(auth.ScopeReference).ForKioskRead())(OperationsHandler(srv, cfg))))
+
+	// Отчет о выработке сотрудников и операций
+	http.HandleFunc("/api/reports/efficiency", api(require(reporting.For(auth.ScopeReports))(EfficiencyReportHandler(srv, cfg))))
+
+	// Производственный план и сравнение плана с фактом
+	http.HandleFunc("/api/plan", api(require(reference.For(auth.ScopeReference))(PlanHandler(srv, cfg))))
+	http.HandleFunc("/api/plan/progress", api(require(reading.For(auth.ScopeReference))(PlanProgressHandler(srv, cfg))))
+
+	// Заказы (партии) и прослеживаемость партий
+	http.HandleFunc("/api/work-orders", api(require(reference.For(auth.ScopeReference).ForKioskRead())(WorkOrdersHandler(srv, cfg))))
+	http.HandleFunc("/api/work-orders/status", api(require(reference.For(auth.ScopeReference))(WorkOrderStatusHandler(srv, cfg))))
+	http.HandleFunc("/api/lots/trace", api(require(reporting.For(auth.ScopeReports))(LotTraceHandler(srv, cfg))))
+
+	// Незавершенное производство по маршрутам деталей
+	http.HandleFunc("/api/wip", api(require(reading.For(auth.ScopeReference))(WIPHandler(srv, cfg))))
+
+	// Доработка брака и отчет о браке с учетом доработки
+	http.HandleFunc("/api/rework", api(require(entry.For(auth.ScopeProduction).ForKiosk())(ReworkHandler(srv, cfg))))
+	http.HandleFunc("/api/reports/defects", api(require(reporting.For(auth.ScopeReports))(DefectReportHandler(srv, cfg))))
+	http.HandleFunc("/api/defects/reasons", api(require(reading.For(auth.ScopeReference).ForKioskRead())(DefectReasonsHandler(cfg))))
+	http.HandleFunc("/api/reports/scrap-cost", api(require(reporting.For(auth.ScopeReports))(ScrapCostReportHandler(srv, cfg))))
+
+	// Статистическое управление процессами: контрольные карты брака
+	http.HandleFunc("/api/spc", api(require(reporting.For(auth.ScopeReports))(SPCHandler(srv, cfg))))
+	http.HandleFunc("/api/spc/chart.svg", api(require(reporting.For(auth.ScopeReports))(SPCChartHandler(srv, cfg))))
+
+	// Реестр оборудования и выпуск по станкам
+	http.HandleFunc("/api/machines", api(require(reference.For(auth.ScopeReference).ForKioskRead())(MachinesHandler(srv, cfg))))
+	http.HandleFunc("/api/reports/machines", api(require(reporting.For(auth.ScopeReports))(MachineReportHandler(srv, cfg))))
+
+	// Журнал простоев оборудования
+	http.HandleFunc("/api/downtime", api(require(entry.For(auth.ScopeDowntime))(DowntimeHandler(srv, cfg))))
+	http.HandleFunc("/api/downtime/start", api(require(entry.For(auth.ScopeDowntime).ForKiosk())(DowntimeStartHandler(srv, cfg))))
+	http.HandleFunc("/api/downtime/stop", api(require(entry.For(auth.ScopeDowntime).ForKiosk())(DowntimeStopHandler(srv, cfg))))
+	http.HandleFunc("/api/downtime/reasons", api(require(reading.For(auth.ScopeReference))(DowntimeReasonsHandler(cfg))))
+	http.HandleFunc("/api/reports/downtime", api(require(reporting.For(auth.ScopeReports))(DowntimeReportHandler(srv, cfg))))
+
+	// Эффективность оборудования (OEE)
+	http.HandleFunc("/api/oee", api(require(reading.For(auth.ScopeReports))(OEEHandler(srv, cfg))))
+	http.HandleFunc("/api/shifts", api(require(reading.For(auth.ScopeReference))(ShiftsHandler(cfg))))
+
+	// Стойкость режущего инструмента
+	http.HandleFunc("/api/tools", api(require(reference.For(auth.ScopeTools))(ToolsHandler(srv, cfg))))
+	http.HandleFunc("/api/tools/change", api(require(entry.For(auth.ScopeTools))(ToolChangeHandler(srv, cfg))))
+	http.HandleFunc("/api/tools/alerts", api(require(reading.For(auth.ScopeTools))(ToolAlertsHandler(srv, cfg))))
+
+	// Склад материалов и спецификации деталей
+	http.HandleFunc("/api/materials", api(require(warehouse.For(auth.ScopeStock))(MaterialsHandler(srv, cfg))))
+	http.HandleFunc("/api/materials/receipts", api(require(warehouse.For(auth.ScopeStock))(MaterialReceiptHandler(srv, cfg))))
+	http.HandleFunc("/api/materials/alerts", api(require(reading.For(auth.ScopeStock))(MaterialAlertsHandler(srv, cfg))))
+	http.HandleFunc("/api/bom", api(require(reference.For(auth.ScopeReference))(BOMHandler(srv, cfg))))
+
+	// Склад готовой продукции и отгрузки
+	http.HandleFunc("/api/stock", api(require(warehouse.For(auth.ScopeStock))(StockHandler(srv, cfg))))
+	http.HandleFunc("/api/stock/movements", api(require(warehouse.For(auth.ScopeStock))(StockMovementsHandler(srv, cfg))))
+	http.HandleFunc("/api/shipments", api(require(warehouse.For(auth.ScopeStock))(ShipmentsHandler(srv, cfg))))
+
+	// Матрица квалификации операторов
+	http.HandleFunc("/api/qualifications", api(require(reference.For(auth.ScopeReference))(QualificationsHandler(srv, cfg))))
+	http.HandleFunc("/api/qualifications/expiring", api(require(reference.For(auth.ScopeReference))(QualificationsExpiringHandler(srv, cfg))))
+
+	// Заявки на ремонт оборудования
+	http.HandleFunc("/api/maintenance", api(require(entry.For(auth.ScopeMaintenance))(MaintenanceHandler(srv, cfg, tickets))))
+	http.HandleFunc("/api/maintenance/update", api(require(reference.For(auth.ScopeMaintenance))(MaintenanceUpdateHandler(srv, cfg, tickets))))
 
 	// Обработчик для проверки состояния сервера (health check)
 	http.HandleFunc("/health", HealthHandler)
+	return nil
+}
+
+// bootstrapAdmin создает администратора при первом запуске, когда пользователей еще нет
+// Пароль берется из переменной окружения ADMIN_PASSWORD, а если она не задана - создается случайный
+// и выводится в лог один раз
+func bootstrapAdmin(am *auth.Manager) error {
+	if am.HasUsers() {
+		return nil
+	}
+
+	password := os.Getenv("ADMIN_PASSWORD")
+	generated := password == ""
+	if generated {
+		b := make([]byte, 9)
+		if _, err := rand.Read(b); err != nil {
+			return err
+		}
+		password = hex.EncodeToString(b)
+	}
+	if _, err := am.CreateUser("admin", "Администратор", auth.RoleAdmin, password, ""); err != nil {
+		return err
+	}
+	if generated {
+		log.Printf("Создан пользователь admin с паролем %s; смените пароль после входа", password)
+	} else {
+		log.Printf("Создан пользователь admin с паролем из ADMIN_PASSWORD")
+	}
+	return nil
 }
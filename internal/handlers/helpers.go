@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/sergekovalev/siberia/internal/reports"
+)
+
+// writeJSON отправляет ответ в формате JSON с указанным HTTP-статусом
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// decodeJSON читает тело запроса в v и при ошибке отвечает 400 (413 для слишком большого тела)
+// с описанием проблемы. Неизвестные поля и данные после JSON-объекта считаются ошибкой,
+// чтобы опечатка в названии поля не приводила к молча пропущенному значению
+func decodeJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	err := dec.Decode(v)
+	if err == nil && dec.Decode(&struct{}{}) != io.EOF {
+		err = errors.New("request body must contain a single JSON object")
+	}
+	if err == nil {
+		return true
+	}
+
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var maxErr *http.MaxBytesError
+	switch {
+	case errors.As(err, &maxErr):
+		http.Error(w, fmt.Sprintf("Request body too large, limit is %d bytes", maxErr.Limit), http.StatusRequestEntityTooLarge)
+		return false
+	case errors.Is(err, io.EOF):
+		err = errors.New("request body is empty")
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		err = errors.New("malformed JSON: unexpected end of body")
+	case errors.As(err, &syntaxErr):
+		err = fmt.Errorf("malformed JSON at position %d", syntaxErr.Offset)
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		err = fmt.Errorf("invalid value for field %q, expected %s", typeErr.Field, typeErr.Type)
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		err = fmt.Errorf("unknown field %s", strings.TrimPrefix(err.Error(), "json: unknown field "))
+	}
+	http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+	return false
+}
+
+// today возвращает текущую дату без времени (в UTC, как и даты, прочитанные из таблицы)
+func today() time.Time {
+	now := time.Now()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// maxPeriodDays - наибольшая длина периода отчета; отчеты по сменам перебирают каждый день периода
+const maxPeriodDays = 366
+
+// parsePeriod читает параметры отчета from, to (YYYY-MM-DD) и group (day, week, month) из строки запроса
+// По умолчанию отчет строится с начала текущего месяца по сегодняшний день с группировкой по дням.
+// Период длиннее maxPeriodDays дней не принимается
+func parsePeriod(r *http.Request) (from, to time.Time, group string, err error) {
+	to = today()
+	from = time.Date(to.Year(), to.Month(), 1, 0, 0, 0, 0, time.UTC)
+	group = reports.GroupDay
+
+	query := r.URL.Query()
+	if v := query.Get("from"); v != "" {
+		if from, err = time.Parse("2006-01-02", v); err != nil {
+			return from, to, group, fmt.Errorf("invalid 'from' date, expected YYYY-MM-DD")
+		}
+	}
+	if v := query.Get("to"); v != "" {
+		if to, err = time.Parse("2006-01-02", v); err != nil {
+			return from, to, group, fmt.Errorf("invalid 'to' date, expected YYYY-MM-DD")
+		}
+	}
+	if from.After(to) {
+		return from, to, group, fmt.Errorf("'from' must not be after 'to'")
+	}
+	if to.Sub(from) >= maxPeriodDays*24*time.Hour {
+		return from, to, group, fmt.Errorf("period must not exceed %d days", maxPeriodDays)
+	}
+	if v := query.Get("group"); v != "" {
+		if !reports.ValidGroup(v) {
+			return from, to, group, fmt.Errorf("invalid 'group', expected day, week or month")
+		}
+		group = v
+	}
+	return from, to, group, nil
+}
+
+// validDate проверяет, что строка содержит дату в формате YYYY-MM-DD
+func validDate(s string) bool {
+	_, err := time.Parse("2006-01-02", s)
+	return err == nil
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDecodeJSON(t *testing.T) {
+	type payload struct {
+		Name  string `json:"name"`
+		Hours int    `json:"hours"`
+	}
+
+	tests := []struct {
+		name     string
+		body     string
+		limit    int64 // Ограничение размера тела; 0 - без ограничения
+		ok       bool
+		status   int
+		contains string
+	}{
+		{name: "корректный объект", body: `{"name": "Иванов", "hours": 8}`, ok: true},
+		{name: "пустое тело", body: ``, status: http.StatusBadRequest, contains: "request body is empty"},
+		{name: "неизвестное поле", body: `{"name": "Иванов", "hourz": 8}`, status: http.StatusBadRequest, contains: `unknown field "hourz"`},
+		{name: "неверный тип", body: `{"hours": "восемь"}`, status: http.StatusBadRequest, contains: `invalid value for field "hours"`},
+		{name: "синтаксическая ошибка", body: `{"name": }`, status: http.StatusBadRequest, contains: "malformed JSON at position"},
+		{name: "обрыв тела", body: `{"name": "Ив`, status: http.StatusBadRequest, contains: "unexpected end of body"},
+		{name: "данные после объекта", body: `{"name": "Иванов"} {"name": "Петров"}`, status: http.StatusBadRequest, contains: "single JSON object"},
+		{name: "слишком большое тело", body: `{"name": "` + strings.Repeat("x", 100) + `"}`, limit: 32, status: http.StatusRequestEntityTooLarge, contains: "limit is 32 bytes"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			if tt.limit > 0 {
+				r.Body = http.MaxBytesReader(w, r.Body, tt.limit)
+			}
+
+			var v payload
+			if ok := decodeJSON(w, r, &v); ok != tt.ok {
+				t.Fatalf("decodeJSON() = %v, want %v (%s)", ok, tt.ok, w.Body.String())
+			}
+			if tt.ok {
+				if v.Name != "Иванов" || v.Hours != 8 {
+					t.Errorf("decoded %+v", v)
+				}
+				return
+			}
+			if w.Code != tt.status {
+				t.Errorf("status = %d, want %d", w.Code, tt.status)
+			}
+			if !strings.Contains(w.Body.String(), tt.contains) {
+				t.Errorf("body = %q, want it to contain %q", w.Body.String(), tt.contains)
+			}
+		})
+	}
+}
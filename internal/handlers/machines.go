@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/sergekovalev/siberia/internal/config"
+	"github.com/sergekovalev/siberia/internal/models"
+)
+
+// machineMu сериализует добавление станков: проверка уникальности идентификатора и запись должны быть атомарными
+var machineMu sync.Mutex
+
+// MachinesHandler обрабатывает запросы к реестру оборудования
+// GET возвращает реестр, POST добавляет станок
+func MachinesHandler(srv *sheets.Service, cfg config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			machines, err := models.ReadMachines(srv, cfg)
+			if err != nil {
+				log.Printf("Error reading machines: %v", err)
+				http.Error(w, "Failed to read machines", http.StatusInternalServerError)
+				return
+			}
+			writeJSON(w, http.StatusOK, machines)
+
+		case http.MethodPost:
+			var m models.Machine
+			if !decodeJSON(w, r, &m) {
+				return
+			}
+
+			// Проверяем обязательные поля
+			m.ID = strings.TrimSpace(m.ID)
+			m.Name = strings.TrimSpace(m.Name)
+			m.Type = strings.TrimSpace(m.Type)
+			m.Workshop = strings.TrimSpace(m.Workshop)
+			if m.ID == "" || m.Name == "" {
+				http.Error(w, "Machine id and name are required", http.StatusBadRequest)
+				return
+			}
+
+			// Идентификатор станка должен быть уникальным; проверка и запись выполняются под machineMu
+			machineMu.Lock()
+			defer machineMu.Unlock()
+
+			existing, err := models.FindMachine(srv, cfg, m.ID)
+			if err != nil {
+				log.Printf("Error reading machines: %v", err)
+				http.Error(w, "Failed to read machines", http.StatusInternalServerError)
+				return
+			}
+			if existing != nil {
+				http.Error(w, "Machine with this id already exists", http.StatusConflict)
+				return
+			}
+
+			if err := models.AppendMachine(srv, cfg, m); err != nil {
+				log.Printf("Error writing machine: %v", err)
+				http.Error(w, "Failed to save machine", http.StatusInternalServerError)
+				return
+			}
+			writeJSON(w, http.StatusCreated, map[string]string{"status": "success"})
+
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
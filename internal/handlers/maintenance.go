@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/sergekovalev/siberia/internal/config"
+	"github.com/sergekovalev/siberia/internal/models"
+	"github.com/sergekovalev/siberia/internal/store"
+	"github.com/sergekovalev/siberia/internal/utils"
+)
+
+// MaintenanceHandler обрабатывает запросы к заявкам на ремонт оборудования
+// GET возвращает заявки (параметры status и machine фильтруют список, новые заявки - первыми),
+// POST создает заявку: {"machine", "description", "priority", "reporter"}; автор берется из сессии, если в ней есть имя
+func MaintenanceHandler(srv *sheets.Service, cfg config.Config, tickets *store.Collection[models.MaintenanceTicket]) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			status := r.URL.Query().Get("status")
+			machine := r.URL.Query().Get("machine")
+
+			result := make([]models.MaintenanceTicket, 0)
+			for _, t := range tickets.All() {
+				if status != "" && t.Status != status {
+					continue
+				}
+				if machine != "" && t.Machine != machine {
+					continue
+				}
+				result = append(result, t)
+			}
+			sort.SliceStable(result, func(i, j int) bool {
+				return result[i].CreatedAt.After(result[j].CreatedAt)
+			})
+			writeJSON(w, http.StatusOK, result)
+
+		case http.MethodPost:
+			var req struct {
+				Machine     string `json:"machine"`
+				Description string `json:"description"`
+				Priority    string `json:"priority"`
+				Reporter    string `json:"reporter"`
+			}
+			if !decodeJSON(w, r, &req) {
+				return
+			}
+
+			// Проверяем обязательные поля
+			req.Machine = strings.TrimSpace(req.Machine)
+			req.Description = strings.TrimSpace(req.Description)
+			if req.Machine == "" || req.Description == "" {
+				http.Error(w, "Machine and description are required", http.StatusBadRequest)
+				return
+			}
+			if req.Priority == "" {
+				req.Priority = models.PriorityNormal
+			}
+			if !models.ValidPriority(req.Priority) {
+				http.Error(w, "Invalid priority, expected low, normal, high or critical", http.StatusBadRequest)
+				return
+			}
+
+			machine, err := models.FindMachine(srv, cfg, req.Machine)
+			if err != nil {
+				log.Printf("Error reading machines: %v", err)
+				http.Error(w, "Failed to save ticket", http.StatusInternalServerError)
+				return
+			}
+			if machine == nil {
+				http.Error(w, "Unknown machine", http.StatusBadRequest)
+				return
+			}
+
+			now := time.Now()
+			ticket := models.MaintenanceTicket{
+				ID:          utils.NewID(),
+				Machine:     req.Machine,
+				Description: req.Description,
+				Priority:    req.Priority,
+				Status:      models.TicketOpen,
+				Reporter:    authorName(r, req.Reporter), // Автор - вошедший пользователь
+				CreatedAt:   now,
+				UpdatedAt:   now,
+			}
+			if err := tickets.Add(ticket); err != nil {
+				log.Printf("Error saving maintenance ticket: %v", err)
+				http.Error(w, "Failed to save ticket", http.StatusInternalServerError)
+				return
+			}
+
+			// Копия в таблице не обязательна: ошибка записи не мешает созданию заявки,
+			// копия будет записана при следующем изменении заявки
+			if row, err := models.AppendMaintenanceMirror(srv, cfg, ticket); err != nil {
+				log.Printf("Error mirroring maintenance ticket %s: %v", ticket.ID, err)
+			} else {
+				id := ticket.ID
+				updated, _, err := tickets.Update(func(t models.MaintenanceTicket) bool { return t.ID == id }, func(t *models.MaintenanceTicket) error {
+					t.MirrorRow = row
+					return nil
+				})
+				if err != nil {
+					log.Printf("Error saving maintenance ticket %s: %v", id, err)
+				} else {
+					ticket = updated
+				}
+			}
+			log.Printf("Maintenance ticket %s created for machine %s", ticket.ID, ticket.Machine)
+			writeJSON(w, http.StatusCreated, ticket)
+
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// MaintenanceUpdateHandler изменяет статус, исполнителя или приоритет заявки на ремонт
+// Тело запроса: {"id", "status", "assignee", "priority"}; пустые поля не изменяются
+func MaintenanceUpdateHandler(srv *sheets.Service, cfg config.Config, tickets *store.Collection[models.MaintenanceTicket]) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req struct {
+			ID       string `json:"id"`
+			Status   string `json:"status"`
+			Assignee string `json:"assignee"`
+			Priority string `json:"priority"`
+		}
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+		if req.Status != "" && !models.ValidTicketStatus(req.Status) {
+			http.Error(w, "Invalid status, expected open, in_progress or closed", http.StatusBadRequest)
+			return
+		}
+		if req.Priority != "" && !models.ValidPriority(req.Priority) {
+			http.Error(w, "Invalid priority, expected low, normal, high or critical", http.StatusBadRequest)
+			return
+		}
+
+		id := strings.TrimSpace(req.ID)
+		ticket, found, err := tickets.Update(func(t models.MaintenanceTicket) bool { return t.ID == id }, func(t *models.MaintenanceTicket) error {
+			now := time.Now()
+			if req.Status != "" && req.Status != t.Status {
+				t.Status = req.Status
+				if t.Status == models.TicketClosed {
+					t.ClosedAt = &now
+				} else {
+					t.ClosedAt = nil // Заявка переоткрыта
+				}
+			}
+			if assignee := strings.TrimSpace(req.Assignee); assignee != "" {
+				t.Assignee = assignee
+			}
+			if req.Priority != "" {
+				t.Priority = req.Priority
+			}
+			t.UpdatedAt = now
+			return nil
+		})
+		if err != nil {
+			log.Printf("Error updating maintenance ticket %s: %v", id, err)
+			http.Error(w, "Failed to update ticket", http.StatusInternalServerError)
+			return
+		}
+		if !found {
+			http.Error(w, "Ticket not found", http.StatusNotFound)
+			return
+		}
+
+		// Обновляем копию в таблице; строка могла не записаться при создании заявки
+		row, err := models.UpdateMaintenanceMirror(srv, cfg, ticket)
+		if err != nil {
+			log.Printf("Error mirroring maintenance ticket %s: %v", ticket.ID, err)
+		} else if row != ticket.MirrorRow {
+			ticket, _, err = tickets.Update(func(t models.MaintenanceTicket) bool { return t.ID == id }, func(t *models.MaintenanceTicket) error {
+				t.MirrorRow = row
+				return nil
+			})
+			if err != nil {
+				log.Printf("Error saving maintenance ticket %s: %v", id, err)
+			}
+		}
+		writeJSON(w, http.StatusOK, ticket)
+	}
+}
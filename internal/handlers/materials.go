@@ -0,0 +1,289 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/sergekovalev/siberia/internal/config"
+	"github.com/sergekovalev/siberia/internal/models"
+)
+
+// MaterialsHandler обрабатывает запросы к справочнику материалов
+// GET возвращает материалы с остатками, POST добавляет материал: {"code", "name", "unit", "stock", "minStock", "price"}
+func MaterialsHandler(srv *sheets.Service, cfg config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			materials, err := models.ReadMaterials(srv, cfg)
+			if err != nil {
+				log.Printf("Error reading materials: %v", err)
+				http.Error(w, "Failed to read materials", http.StatusInternalServerError)
+				return
+			}
+			writeJSON(w, http.StatusOK, materials)
+
+		case http.MethodPost:
+			var m models.Material
+			if !decodeJSON(w, r, &m) {
+				return
+			}
+
+			// Проверяем обязательные поля
+			m.Code = strings.TrimSpace(m.Code)
+			m.Name = strings.TrimSpace(m.Name)
+			m.Unit = strings.TrimSpace(m.Unit)
+			if m.Code == "" || m.Name == "" || m.Unit == "" {
+				http.Error(w, "Material code, name and unit are required", http.StatusBadRequest)
+				return
+			}
+			if m.Stock < 0 || m.MinStock < 0 || m.Price < 0 {
+				http.Error(w, "Stock values and price must not be negative", http.StatusBadRequest)
+				return
+			}
+
+			existing, err := models.FindMaterial(srv, cfg, m.Code)
+			if err != nil {
+				log.Printf("Error reading materials: %v", err)
+				http.Error(w, "Failed to save material", http.StatusInternalServerError)
+				return
+			}
+			if existing != nil {
+				http.Error(w, "Material with this code already exists", http.StatusConflict)
+				return
+			}
+
+			if err := models.AppendMaterial(srv, cfg, m); err != nil {
+				log.Printf("Error writing material: %v", err)
+				http.Error(w, "Failed to save material", http.StatusInternalServerError)
+				return
+			}
+			writeJSON(w, http.StatusCreated, map[string]string{"status": "success"})
+
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// MaterialReceiptHandler оприходует поступление материала на склад
+// Тело запроса: {"date", "material", "quantity", "document", "fullName"}; в ответе - материал с новым остатком
+func MaterialReceiptHandler(srv *sheets.Service, cfg config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var receipt models.MaterialReceipt
+		if !decodeJSON(w, r, &receipt) {
+			return
+		}
+
+		receipt.Material = strings.TrimSpace(receipt.Material)
+		receipt.Document = strings.TrimSpace(receipt.Document)
+		receipt.FullName = strings.TrimSpace(receipt.FullName)
+		if receipt.Material == "" || receipt.Quantity <= 0 {
+			http.Error(w, "Material and positive quantity are required", http.StatusBadRequest)
+			return
+		}
+		if receipt.Date != "" && !validDate(receipt.Date) {
+			http.Error(w, "Invalid date, expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+
+		material, err := models.FindMaterial(srv, cfg, receipt.Material)
+		if err != nil {
+			log.Printf("Error reading materials: %v", err)
+			http.Error(w, "Failed to process data", http.StatusInternalServerError)
+			return
+		}
+		if material == nil {
+			http.Error(w, "Unknown material", http.StatusBadRequest)
+			return
+		}
+
+		updated, err := models.ReceiveMaterial(srv, cfg, receipt)
+		if err != nil {
+			log.Printf("Error receiving material: %v", err)
+			http.Error(w, "Failed to process data", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusCreated, updated)
+	}
+}
+
+// MaterialAlertsHandler возвращает материалы, остаток которых не превышает минимальный
+func MaterialAlertsHandler(srv *sheets.Service, cfg config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		materials, err := models.ReadMaterials(srv, cfg)
+		if err != nil {
+			log.Printf("Error reading materials: %v", err)
+			http.Error(w, "Failed to read materials", http.StatusInternalServerError)
+			return
+		}
+
+		type alert struct {
+			models.Material
+			Message string `json:"message"`
+		}
+		alerts := make([]alert, 0)
+		for _, m := range materials {
+			if m.Low() {
+				alerts = append(alerts, alert{Material: m, Message: materialWarning(m)})
+			}
+		}
+		writeJSON(w, http.StatusOK, alerts)
+	}
+}
+
+// BOMHandler обрабатывает запросы к спецификациям деталей
+// GET возвращает нормы расхода (параметр part фильтрует по детали),
+// POST добавляет или изменяет норму расхода: {"part", "material", "perPiece"}
+func BOMHandler(srv *sheets.Service, cfg config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			lines, err := models.ReadBOM(srv, cfg)
+			if err != nil {
+				log.Printf("Error reading bill of materials: %v", err)
+				http.Error(w, "Failed to read bill of materials", http.StatusInternalServerError)
+				return
+			}
+			if part := r.URL.Query().Get("part"); part != "" {
+				lines = bomForPart(lines, part)
+			}
+			writeJSON(w, http.StatusOK, lines)
+
+		case http.MethodPost:
+			var line models.BOMLine
+			if !decodeJSON(w, r, &line) {
+				return
+			}
+
+			line.Part = strings.TrimSpace(line.Part)
+			line.Material = strings.TrimSpace(line.Material)
+			if line.Part == "" || line.Material == "" || line.PerPiece <= 0 {
+				http.Error(w, "Part, material and positive consumption per piece are required", http.StatusBadRequest)
+				return
+			}
+
+			material, err := models.FindMaterial(srv, cfg, line.Material)
+			if err != nil {
+				log.Printf("Error reading materials: %v", err)
+				http.Error(w, "Failed to save bill of materials", http.StatusInternalServerError)
+				return
+			}
+			if material == nil {
+				http.Error(w, "Unknown material", http.StatusBadRequest)
+				return
+			}
+
+			if err := models.SaveBOMLine(srv, cfg, line); err != nil {
+				log.Printf("Error saving bill of materials: %v", err)
+				http.Error(w, "Failed to save bill of materials", http.StatusInternalServerError)
+				return
+			}
+			writeJSON(w, http.StatusOK, map[string]string{"status": "success"})
+
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// consumeMaterials списывает материалы по спецификации детали на parts деталей
+// и возвращает предупреждения о материалах с остатком ниже минимального
+func consumeMaterials(srv *sheets.Service, cfg config.Config, part string, parts int) []string {
+	warnings := make([]string, 0)
+
+	lines, err := models.ReadBOM(srv, cfg)
+	if err != nil {
+		log.Printf("Error reading bill of materials: %v", err)
+		return warnings
+	}
+	lines = bomForPart(lines, part)
+	if len(lines) == 0 {
+		return warnings
+	}
+
+	materials, err := models.ConsumeMaterials(srv, cfg, lines, parts)
+	if err != nil {
+		log.Printf("Error consuming materials: %v", err)
+	}
+	for _, m := range materials {
+		if m.Low() {
+			warnings = append(warnings, materialWarning(m))
+		}
+	}
+	return warnings
+}
+
+// previewMaterials проверяет без списания, какие материалы спецификации детали окажутся ниже
+// минимального остатка после обработки parts деталей
+func previewMaterials(srv *sheets.Service, cfg config.Config, part string, parts int) []string {
+	warnings := make([]string, 0)
+
+	lines, err := models.ReadBOM(srv, cfg)
+	if err != nil {
+		log.Printf("Error reading bill of materials: %v", err)
+		return warnings
+	}
+	lines = bomForPart(lines, part)
+	if len(lines) == 0 {
+		return warnings
+	}
+
+	materials, err := models.ReadMaterials(srv, cfg)
+	if err != nil {
+		log.Printf("Error reading materials: %v", err)
+		return warnings
+	}
+	byCode := make(map[string]models.Material, len(materials))
+	for _, m := range materials {
+		byCode[m.Code] = m
+	}
+	used := make([]string, 0, len(lines))
+	for _, line := range lines {
+		m, ok := byCode[line.Material]
+		if !ok {
+			continue
+		}
+		if !slices.Contains(used, m.Code) {
+			used = append(used, m.Code)
+		}
+		m.Stock -= line.PerPiece * float64(parts)
+		byCode[m.Code] = m // Один материал может встречаться в спецификации несколько раз
+	}
+	for _, code := range used {
+		if m := byCode[code]; m.Low() {
+			warnings = append(warnings, materialWarning(m))
+		}
+	}
+	return warnings
+}
+
+// bomForPart оставляет строки спецификации указанной детали
+func bomForPart(lines []models.BOMLine, part string) []models.BOMLine {
+	filtered := make([]models.BOMLine, 0)
+	for _, line := range lines {
+		if line.Part == part {
+			filtered = append(filtered, line)
+		}
+	}
+	return filtered
+}
+
+// materialWarning формирует предупреждение о низком остатке материала
+func materialWarning(m models.Material) string {
+	return fmt.Sprintf("Материал %s (%s): осталось %v %s при минимуме %v %s", m.Name, m.Code, m.Stock, m.Unit, m.MinStock, m.Unit)
+}
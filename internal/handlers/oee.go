@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/sergekovalev/siberia/internal/config"
+	"github.com/sergekovalev/siberia/internal/models"
+	"github.com/sergekovalev/siberia/internal/reports"
+)
+
+// OEEHandler возвращает показатели OEE по станкам
+// Параметры: from, to (YYYY-MM-DD), group (shift, day, week, month), machine - фильтр по станку
+func OEEHandler(srv *sheets.Service, cfg config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		// Группировка по сменам поддерживается только этим отчетом, поэтому разбираем ее отдельно
+		query := r.URL.Query()
+		group := query.Get("group")
+		if group == reports.GroupShift {
+			query.Del("group")
+			r.URL.RawQuery = query.Encode()
+		}
+		from, to, periodGroup, err := parsePeriod(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if group != reports.GroupShift {
+			group = periodGroup
+		}
+
+		var in reports.OEEInput
+		in.Shifts = cfg.Shifts
+		if in.Records, err = models.ReadProductionData(srv, cfg); err != nil {
+			log.Printf("Error reading production data: %v", err)
+			http.Error(w, "Failed to read production data", http.StatusInternalServerError)
+			return
+		}
+		if in.Downtime, err = models.ReadDowntime(srv, cfg); err != nil {
+			log.Printf("Error reading downtime: %v", err)
+			http.Error(w, "Failed to read downtime", http.StatusInternalServerError)
+			return
+		}
+		if in.Operations, err = models.ReadOperations(srv, cfg); err != nil {
+			log.Printf("Error reading operations: %v", err)
+			http.Error(w, "Failed to read operations", http.StatusInternalServerError)
+			return
+		}
+		if in.Machines, err = models.ReadMachines(srv, cfg); err != nil {
+			log.Printf("Error reading machines: %v", err)
+			http.Error(w, "Failed to read machines", http.StatusInternalServerError)
+			return
+		}
+
+		rows := reports.OEE(in, from, to, time.Now(), group)
+		if machine := query.Get("machine"); machine != "" {
+			filtered := rows[:0]
+			for _, row := range rows {
+				if row.Machine == machine {
+					filtered = append(filtered, row)
+				}
+			}
+			rows = filtered
+		}
+		writeJSON(w, http.StatusOK, rows)
+	}
+}
+
+// ShiftsHandler возвращает настроенные рабочие смены
+func ShiftsHandler(cfg config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		writeJSON(w, http.StatusOK, cfg.Shifts)
+	}
+}
@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/sergekovalev/siberia/internal/config"
+	"github.com/sergekovalev/siberia/internal/models"
+)
+
+// operationMu сериализует изменения справочника операций: новые значения объединяются с прежними
+var operationMu sync.Mutex
+
+// OperationsHandler обрабатывает запросы к справочнику операций с нормами времени
+// GET возвращает справочник, POST добавляет операцию или обновляет ее нормы;
+// при обновлении меняются только переданные поля
+func OperationsHandler(srv *sheets.Service, cfg config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			operations, err := models.ReadOperations(srv, cfg)
+			if err != nil {
+				log.Printf("Error reading operations: %v", err)
+				http.Error(w, "Failed to read operations", http.StatusInternalServerError)
+				return
+			}
+			writeJSON(w, http.StatusOK, operations)
+
+		case http.MethodPost:
+			// Поля, которых нет в запросе, сохраняют прежние значения операции
+			var req struct {
+				Name         string   `json:"name"`
+				CycleMinutes *float64 `json:"cycleMinutes"`
+				SetupMinutes *float64 `json:"setupMinutes"`
+				Part         *string  `json:"part"`
+				Sequence     *int     `json:"sequence"`
+				LabourCost   *float64 `json:"labourCost"`
+			}
+			if !decodeJSON(w, r, &req) {
+				return
+			}
+
+			// Проверяем название операции
+			req.Name = strings.TrimSpace(req.Name)
+			if req.Name == "" {
+				http.Error(w, "Operation name is required", http.StatusBadRequest)
+				return
+			}
+
+			// Чтение прежней операции и запись изменений не должны чередоваться с другим изменением
+			operationMu.Lock()
+			defer operationMu.Unlock()
+
+			operations, err := models.ReadOperations(srv, cfg)
+			if err != nil {
+				log.Printf("Error reading operations: %v", err)
+				http.Error(w, "Failed to save operation", http.StatusInternalServerError)
+				return
+			}
+			op, ok := models.OperationsByName(operations)[req.Name]
+			if !ok {
+				op = models.Operation{Name: req.Name}
+			}
+			if req.CycleMinutes != nil {
+				op.CycleMinutes = *req.CycleMinutes
+			}
+			if req.SetupMinutes != nil {
+				op.SetupMinutes = *req.SetupMinutes
+			}
+			if req.Part != nil {
+				op.Part = strings.TrimSpace(*req.Part)
+			}
+			if req.Sequence != nil {
+				op.Sequence = *req.Sequence
+			}
+			if req.LabourCost != nil {
+				op.LabourCost = *req.LabourCost
+			}
+
+			// Проверяем нормы времени и место в маршруте с учетом прежних значений
+			if op.CycleMinutes < 0 || op.SetupMinutes < 0 {
+				http.Error(w, "Standard times must not be negative", http.StatusBadRequest)
+				return
+			}
+			if op.LabourCost < 0 {
+				http.Error(w, "Labour cost must not be negative", http.StatusBadRequest)
+				return
+			}
+			if op.Sequence < 0 || (op.Sequence > 0 && op.Part == "") {
+				http.Error(w, "Routing sequence requires a part and must not be negative", http.StatusBadRequest)
+				return
+			}
+
+			if err := models.SaveOperation(srv, cfg, op); err != nil {
+				log.Printf("Error saving operation: %v", err)
+				http.Error(w, "Failed to save operation", http.StatusInternalServerError)
+				return
+			}
+			writeJSON(w, http.StatusCreated, map[string]string{"status": "success"})
+
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
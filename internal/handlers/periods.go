@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sergekovalev/siberia/internal/auth"
+	"github.com/sergekovalev/siberia/internal/models"
+	"github.com/sergekovalev/siberia/internal/store"
+	"github.com/sergekovalev/siberia/internal/utils"
+)
+
+// periodMu сериализует закрытие и открытие периодов (проверка пересечения и сохранение)
+// Запись с датой удерживает periodMu на чтение от проверки периода (checkOpenPeriod) до записи в таблицу,
+// чтобы одновременное закрытие периода не пропустило в него запись
+var periodMu sync.RWMutex
+
+// PeriodsHandler возвращает закрытые периоды, последние - первыми
+func PeriodsHandler(periods *store.Collection[models.ClosedPeriod]) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		result := periods.All()
+		sort.Slice(result, func(i, j int) bool { return result[i].From > result[j].From })
+		writeJSON(w, http.StatusOK, result)
+	}
+}
+
+// PeriodCloseHandler закрывает период: POST {"month": "YYYY-MM"} или {"from", "to"} и {"note"}
+// После закрытия записи о выпуске и табель с датой внутри периода не принимаются
+func PeriodCloseHandler(periods *store.Collection[models.ClosedPeriod], audit *store.Collection[models.AuditEntry]) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req struct {
+			Month string `json:"month"`
+			From  string `json:"from"`
+			To    string `json:"to"`
+			Note  string `json:"note"`
+		}
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+
+		// Месяц задает период с первого по последний день месяца
+		if req.Month != "" {
+			month, err := time.Parse("2006-01", req.Month)
+			if err != nil {
+				http.Error(w, "Invalid month format, expected YYYY-MM", http.StatusBadRequest)
+				return
+			}
+			req.From = month.Format("2006-01-02")
+			req.To = month.AddDate(0, 1, -1).Format("2006-01-02")
+		}
+		if !validDate(req.From) || !validDate(req.To) {
+			http.Error(w, "Month or from and to dates (YYYY-MM-DD) are required", http.StatusBadRequest)
+			return
+		}
+		if req.From > req.To {
+			http.Error(w, "'from' must not be after 'to'", http.StatusBadRequest)
+			return
+		}
+
+		u, _ := auth.UserFromContext(r.Context())
+		period := models.ClosedPeriod{
+			ID:       utils.NewID(),
+			From:     req.From,
+			To:       req.To,
+			Note:     strings.TrimSpace(req.Note),
+			ClosedBy: u.FullName,
+			ClosedAt: time.Now(),
+		}
+
+		periodMu.Lock()
+		defer periodMu.Unlock()
+
+		for _, p := range periods.All() {
+			if p.Overlaps(period) {
+				http.Error(w, fmt.Sprintf("Period overlaps closed period %s – %s", p.From, p.To), http.StatusConflict)
+				return
+			}
+		}
+		if err := periods.Add(period); err != nil {
+			log.Printf("Error saving closed period: %v", err)
+			http.Error(w, "Failed to close period", http.StatusInternalServerError)
+			return
+		}
+		if err := writeAudit(r, audit, models.AuditPeriodClose, fmt.Sprintf("%s – %s %s", period.From, period.To, period.Note)); err != nil {
+			log.Printf("Error writing audit log: %v", err)
+		}
+		log.Printf("Period %s – %s closed by %s", period.From, period.To, u.Username)
+		writeJSON(w, http.StatusCreated, period)
+	}
+}
+
+// PeriodReopenHandler открывает закрытый период: POST {"id", "reason"}
+// Причина обязательна и вместе с периодом записывается в журнал аудита
+func PeriodReopenHandler(periods *store.Collection[models.ClosedPeriod], audit *store.Collection[models.AuditEntry]) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req struct {
+			ID     string `json:"id"`
+			Reason string `json:"reason"`
+		}
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+		if req.Reason = strings.TrimSpace(req.Reason); req.ID == "" || req.Reason == "" {
+			http.Error(w, "Period id and reason are required", http.StatusBadRequest)
+			return
+		}
+
+		periodMu.Lock()
+		defer periodMu.Unlock()
+
+		period, found := periods.Find(func(p models.ClosedPeriod) bool { return p.ID == req.ID })
+		if !found {
+			http.Error(w, "Period not found", http.StatusNotFound)
+			return
+		}
+
+		// Сначала записываем действие в журнал: открытие периода без записи в журнале недопустимо
+		details := fmt.Sprintf("%s – %s: %s", period.From, period.To, req.Reason)
+		if err := writeAudit(r, audit, models.AuditPeriodReopen, details); err != nil {
+			log.Printf("Error writing audit log: %v", err)
+			http.Error(w, "Failed to reopen period", http.StatusInternalServerError)
+			return
+		}
+		if _, err := periods.Delete(func(p models.ClosedPeriod) bool { return p.ID == req.ID }); err != nil {
+			log.Printf("Error deleting closed period: %v", err)
+			http.Error(w, "Failed to reopen period", http.StatusInternalServerError)
+			return
+		}
+		log.Printf("Period %s – %s reopened: %s", period.From, period.To, req.Reason)
+		writeJSON(w, http.StatusOK, map[string]string{"status": "success"})
+	}
+}
+
+// AuditHandler возвращает журнал аудита, новые записи - первыми
+// Параметры: action - действие, from и to (YYYY-MM-DD) - период
+func AuditHandler(audit *store.Collection[models.AuditEntry]) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		query := r.URL.Query()
+		action := query.Get("action")
+		from, to := query.Get("from"), query.Get("to")
+		if (from != "" && !validDate(from)) || (to != "" && !validDate(to)) {
+			http.Error(w, "Invalid date format, expected YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+
+		result := make([]models.AuditEntry, 0)
+		for _, e := range audit.All() {
+			day := e.Time.Format("2006-01-02")
+			if (action != "" && e.Action != action) || (from != "" && day < from) || (to != "" && day > to) {
+				continue
+			}
+			result = append(result, e)
+		}
+		sort.SliceStable(result, func(i, j int) bool { return result[i].Time.After(result[j].Time) })
+		writeJSON(w, http.StatusOK, result)
+	}
+}
+
+// writeAudit добавляет в журнал аудита действие пользователя, выполнившего запрос
+func writeAudit(r *http.Request, audit *store.Collection[models.AuditEntry], action, details string) error {
+	u, _ := auth.UserFromContext(r.Context())
+	return audit.Add(models.AuditEntry{
+		ID:      utils.NewID(),
+		Time:    time.Now(),
+		UserID:  u.ID,
+		User:    u.FullName,
+		Action:  action,
+		Details: strings.TrimSpace(details),
+	})
+}
+
+// checkOpenPeriod проверяет, что дата записи не входит в закрытый период
+// Вызывающий удерживает periodMu.RLock до окончания записи. Закрытый период защищает данные для расчета
+// зарплаты - выпуск и табель; доработка, простои и заявки на ремонт в нем не проверяются.
+// Возвращает HTTP-статус и описание проблемы или 0 и пустую строку
+func checkOpenPeriod(periods *store.Collection[models.ClosedPeriod], date string) (int, string) {
+	d, err := utils.ParseDate(date)
+	if err != nil {
+		return http.StatusBadRequest, "Invalid date"
+	}
+	if p := models.FindClosedPeriod(periods.All(), d.Format("2006-01-02")); p != nil {
+		return http.StatusConflict, fmt.Sprintf("Period %s – %s is closed", p.From, p.To)
+	}
+	return 0, ""
+}
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/sergekovalev/siberia/internal/config"
+	"github.com/sergekovalev/siberia/internal/models"
+	"github.com/sergekovalev/siberia/internal/reports"
+)
+
+// PlanHandler обрабатывает запросы к производственному плану
+// GET возвращает задания, пересекающиеся с периодом from-to, POST добавляет задание
+func PlanHandler(srv *sheets.Service, cfg config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			from, to, _, err := parsePeriod(r)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			targets, err := readPlanForPeriod(srv, cfg, from, to)
+			if err != nil {
+				log.Printf("Error reading plan: %v", err)
+				http.Error(w, "Failed to read plan", http.StatusInternalServerError)
+				return
+			}
+			writeJSON(w, http.StatusOK, targets)
+
+		case http.MethodPost:
+			var target models.PlanTarget
+			if !decodeJSON(w, r, &target) {
+				return
+			}
+
+			// Проверяем обязательные поля и формат периода
+			target.Period = strings.TrimSpace(target.Period)
+			target.PartAndOperation = strings.TrimSpace(target.PartAndOperation)
+			target.Employee = strings.TrimSpace(target.Employee)
+			if target.PartAndOperation == "" || target.Quantity <= 0 {
+				http.Error(w, "Part/operation and positive quantity are required", http.StatusBadRequest)
+				return
+			}
+			if _, _, err := target.Dates(); err != nil {
+				http.Error(w, "Invalid period, expected YYYY-MM-DD or YYYY-Www", http.StatusBadRequest)
+				return
+			}
+
+			if err := models.AppendPlanTarget(srv, cfg, target); err != nil {
+				log.Printf("Error writing plan target: %v", err)
+				http.Error(w, "Failed to save plan target", http.StatusInternalServerError)
+				return
+			}
+			writeJSON(w, http.StatusCreated, map[string]string{"status": "success"})
+
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// PlanProgressHandler возвращает сравнение плана с фактом за период
+// Параметры: from, to (YYYY-MM-DD), employee - фильтр по сотруднику, alerts=1 - только задания, требующие внимания
+func PlanProgressHandler(srv *sheets.Service, cfg config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		from, to, _, err := parsePeriod(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		targets, err := readPlanForPeriod(srv, cfg, from, to)
+		if err != nil {
+			log.Printf("Error reading plan: %v", err)
+			http.Error(w, "Failed to read plan", http.StatusInternalServerError)
+			return
+		}
+
+		// Фильтруем задания по сотруднику
+		if employee := r.URL.Query().Get("employee"); employee != "" {
+			filtered := targets[:0]
+			for _, target := range targets {
+				if target.Employee == employee {
+					filtered = append(filtered, target)
+				}
+			}
+			targets = filtered
+		}
+
+		records, err := models.ReadProductionData(srv, cfg)
+		if err != nil {
+			log.Printf("Error reading production data: %v", err)
+			http.Error(w, "Failed to read production data", http.StatusInternalServerError)
+			return
+		}
+
+		progress := reports.PlanVsFact(targets, records, today())
+		if r.URL.Query().Get("alerts") == "1" {
+			alerts := make([]reports.PlanProgress, 0)
+			for _, p := range progress {
+				if p.Alert {
+					alerts = append(alerts, p)
+				}
+			}
+			progress = alerts
+		}
+		writeJSON(w, http.StatusOK, progress)
+	}
+}
+
+// readPlanForPeriod читает плановые задания, период которых пересекается с from-to
+func readPlanForPeriod(srv *sheets.Service, cfg config.Config, from, to time.Time) ([]models.PlanTarget, error) {
+	targets, err := models.ReadPlan(srv, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]models.PlanTarget, 0, len(targets))
+	for _, target := range targets {
+		start, end, err := target.Dates()
+		if err != nil || end.Before(from) || start.After(to) {
+			continue
+		}
+		result = append(result, target)
+	}
+	return result, nil
+}
@@ -2,8 +2,10 @@ package handlers
 
 import (
 	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -11,10 +13,15 @@ import (
 
 	"github.com/sergekovalev/siberia/internal/config"
 	"github.com/sergekovalev/siberia/internal/models"
+	"github.com/sergekovalev/siberia/internal/reports"
+	"github.com/sergekovalev/siberia/internal/store"
+	"github.com/sergekovalev/siberia/internal/utils"
 )
 
 // ProductionHandler обрабатывает HTTP-запросы для добавления данных о производстве
-func ProductionHandler(srv *sheets.Service, cfg config.Config) http.HandlerFunc {
+// Если записи сотрудника требуют утверждения мастером, запись сохраняется как ожидающая (ответ 202),
+// а в таблицу попадает только после утверждения. Записи с датой в закрытом периоде не принимаются
+func ProductionHandler(srv *sheets.Service, cfg config.Config, submissions *store.Collection[models.Submission], periods *store.Collection[models.ClosedPeriod]) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Проверяем, что метод запроса - POST
 		if r.Method != http.MethodPost {
@@ -24,24 +31,43 @@ func ProductionHandler(srv *sheets.Service, cfg config.Config) http.HandlerFunc
 
 		// Декодируем тело запроса в структуру ProductionData
 		var data models.ProductionData
-		if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
-			http.Error(w, "Invalid request body", http.StatusBadRequest) // Ошибка 400, если тело запроса некорректно
+		if !decodeJSON(w, r, &data) {
 			return
 		}
 
-		// Проверяем обязательные поля: FullName, PartAndOperation и TotalParts
-		if strings.TrimSpace(data.FullName) == "" || strings.TrimSpace(data.PartAndOperation) == "" || strings.TrimSpace(data.TotalParts) == "" {
-			http.Error(w, "Full name, part/operation and total parts are required", http.StatusBadRequest) // Ошибка 400, если поля пустые
+		// Оператор вносит записи только от своего имени
+		if name := sessionFullName(r); name != "" {
+			data.FullName = name
+		}
+
+		warnings, status, problem := validateProduction(srv, cfg, &data)
+		if problem != "" {
+			http.Error(w, problem, status)
+			return
+		}
+		// Период не должен закрыться между проверкой и записью
+		periodMu.RLock()
+		defer periodMu.RUnlock()
+		if status, problem := checkOpenPeriod(periods, data.Date); problem != "" {
+			http.Error(w, problem, status) // Ошибка 409, если период закрыт
 			return
 		}
 
-		// Если дата не указана, устанавливаем текущую дату
-		if data.Date == "" {
-			data.Date = time.Now().Format("2006-01-02") // Форматируем дату в формате YYYY-MM-DD
+		// Запись оператора ждет утверждения мастером
+		if needsApproval(r, cfg) {
+			warnings = append(warnings, previewWarnings(srv, cfg, data)...)
+			submission, err := submit(r, submissions, models.Submission{Kind: models.SubmissionProduction, Production: &data, Warnings: warnings})
+			if err != nil {
+				log.Printf("Error saving submission: %v", err)
+				http.Error(w, "Failed to process data", http.StatusInternalServerError)
+				return
+			}
+			writeJSON(w, http.StatusAccepted, map[string]interface{}{"status": models.SubmissionPending, "id": submission.ID, "warnings": warnings})
+			return
 		}
 
-		// Добавляем данные о производстве в Google Sheets
-		if err := models.AppendProductionData(srv, cfg, data); err != nil {
+		row, more, err := recordProduction(srv, cfg, data)
+		if err != nil {
 			log.Printf("Error writing production data: %v", err)                    // Логируем ошибку
 			http.Error(w, "Failed to process data", http.StatusInternalServerError) // Ошибка 500, если не удалось записать данные
 			return
@@ -49,6 +75,237 @@ func ProductionHandler(srv *sheets.Service, cfg config.Config) http.HandlerFunc
 
 		// Успешный ответ с кодом 201 (Created)
 		w.WriteHeader(http.StatusCreated)
-		json.NewEncoder(w).Encode(map[string]string{"status": "success"}) // Отправляем JSON-ответ с сообщением об успехе
+		json.NewEncoder(w).Encode(map[string]interface{}{"status": "success", "row": row, "warnings": append(warnings, more...)}) // Отправляем JSON-ответ с номером строки и предупреждениями
+	}
+}
+
+// validateProduction проверяет запись о выпуске и дополняет ее значениями по умолчанию (дата, смена)
+// Возвращает предупреждения для оператора, а при ошибке - HTTP-статус и описание проблемы
+func validateProduction(srv *sheets.Service, cfg config.Config, data *models.ProductionData) (warnings []string, status int, problem string) {
+	warnings = make([]string, 0)
+
+	// Проверяем обязательные поля: FullName, PartAndOperation и TotalParts
+	if strings.TrimSpace(data.FullName) == "" || strings.TrimSpace(data.PartAndOperation) == "" || strings.TrimSpace(data.TotalParts) == "" {
+		return warnings, http.StatusBadRequest, "Full name, part/operation and total parts are required" // Ошибка 400, если поля пустые
+	}
+
+	// Если дата не указана, устанавливаем текущую дату
+	if data.Date == "" {
+		data.Date = time.Now().Format("2006-01-02") // Форматируем дату в формате YYYY-MM-DD
+	}
+
+	// Проверяем смену; если смена не указана, подставляем текущую смену, начавшуюся в день записи
+	if data.Shift = strings.TrimSpace(data.Shift); data.Shift != "" {
+		if !cfg.ValidShift(data.Shift) {
+			return warnings, http.StatusBadRequest, "Unknown shift" // Ошибка 400, если смены нет в конфигурации
+		}
+	} else if shift, ok := reports.ShiftAt(cfg.Shifts, time.Now()); ok && shift.Date == data.Date {
+		data.Shift = shift.Name
+	}
+
+	// Проверяем код причины брака
+	if data.DefectReason = strings.TrimSpace(data.DefectReason); data.DefectReason != "" && !cfg.ValidDefectReason(data.DefectReason) {
+		return warnings, http.StatusBadRequest, "Unknown defect reason" // Ошибка 400, если причины нет в конфигурации
+	}
+
+	// Если указана партия, проверяем, что заказ существует, по нему можно вносить записи
+	// и операция относится к детали заказа (если деталь операции указана в справочнике)
+	if data.Lot = strings.TrimSpace(data.Lot); data.Lot != "" {
+		workOrder, err := models.FindWorkOrder(srv, cfg, data.Lot)
+		if err != nil {
+			log.Printf("Error reading work orders: %v", err)
+			return warnings, http.StatusInternalServerError, "Failed to process data"
+		}
+		if workOrder == nil {
+			return warnings, http.StatusBadRequest, "Unknown lot number" // Ошибка 400, если партия не найдена
+		}
+		if !workOrder.Active() {
+			return warnings, http.StatusBadRequest, "Lot is already completed or cancelled" // Ошибка 400, если заказ закрыт
+		}
+
+		// Операция из маршрута другой детали не может выполняться по этой партии
+		operations, err := models.ReadOperations(srv, cfg)
+		if err != nil {
+			log.Printf("Error reading operations: %v", err)
+			return warnings, http.StatusInternalServerError, "Failed to process data"
+		}
+		if op, ok := models.OperationsByName(operations)[strings.TrimSpace(data.PartAndOperation)]; ok && op.Part != "" && op.Part != workOrder.Part {
+			return warnings, http.StatusBadRequest, "Operation belongs to part " + op.Part + ", but the lot is for part " + workOrder.Part
+		}
+	}
+
+	// Если указан станок, проверяем, что он есть в реестре оборудования
+	if data.Machine = strings.TrimSpace(data.Machine); data.Machine != "" {
+		machine, err := models.FindMachine(srv, cfg, data.Machine)
+		if err != nil {
+			log.Printf("Error reading machines: %v", err)
+			return warnings, http.StatusInternalServerError, "Failed to process data"
+		}
+		if machine == nil {
+			return warnings, http.StatusBadRequest, "Unknown machine" // Ошибка 400, если станок не найден
+		}
+	}
+
+	// Проверяем допуск сотрудника к операции по матрице квалификации
+	// Пока матрица не заведена (листа нет или он пуст), проверка не выполняется: запись не должна
+	// отклоняться или получать предупреждение только потому, что квалификацию еще не внесли
+	if cfg.QualificationMode != config.QualificationOff {
+		// Ошибка чтения не означает отсутствие матрицы: в режиме reject запись не принимается без проверки
+		qualifications, err := models.ReadQualifications(srv, cfg)
+		if err != nil {
+			if cfg.QualificationMode == config.QualificationReject {
+				log.Printf("Error reading qualifications: %v", err)
+				return warnings, http.StatusInternalServerError, "Failed to process data"
+			}
+			log.Printf("Warning: qualification check skipped, failed to read qualifications: %v", err)
+			return warnings, 0, ""
+		}
+		if len(qualifications) == 0 {
+			log.Printf("Warning: qualification check skipped, sheet %q is missing or empty", cfg.QualificationsSheet)
+			return warnings, 0, ""
+		}
+		date, err := utils.ParseDate(data.Date)
+		if err != nil {
+			return warnings, http.StatusBadRequest, "Invalid date" // Ошибка 400, если дата не распознана
+		}
+		problem := checkQualification(qualifications, cfg.QualificationMinLevel, strings.TrimSpace(data.FullName), strings.TrimSpace(data.PartAndOperation), date)
+		if problem != "" {
+			if cfg.QualificationMode == config.QualificationReject {
+				return warnings, http.StatusForbidden, problem // Ошибка 403, если сотрудник не допущен к операции
+			}
+			warnings = append(warnings, problem)
+		}
+	}
+	return warnings, 0, ""
+}
+
+// recordProduction записывает проверенную запись о выпуске в таблицу и выполняет связанные действия:
+// переводит заказ в работу, списывает стойкость инструмента и материал, оприходует готовую продукцию.
+// Возвращает номер строки записи и предупреждения; ошибки связанных действий только логируются
+func recordProduction(srv *sheets.Service, cfg config.Config, data models.ProductionData) (int, []string, error) {
+	// Добавляем данные о производстве в Google Sheets
+	row, err := models.AppendProductionData(srv, cfg, data)
+	if err != nil {
+		return 0, nil, err
+	}
+	warnings := make([]string, 0)
+
+	// Первая запись по открытому заказу переводит его в работу
+	if data.Lot != "" {
+		workOrder, err := models.FindWorkOrder(srv, cfg, data.Lot)
+		if err != nil {
+			log.Printf("Error reading work orders: %v", err)
+		} else if workOrder != nil && workOrder.Status == models.WorkOrderOpen {
+			if err := models.UpdateWorkOrderStatus(srv, cfg, *workOrder, models.WorkOrderInProgress); err != nil {
+				log.Printf("Error updating work order status: %v", err)
+			}
+		}
+	}
+
+	total, err := strconv.Atoi(strings.TrimSpace(data.TotalParts))
+	if err != nil {
+		total = 0 // Количество записано в таблицу как есть; списывать нечего
+	}
+
+	// Списываем стойкость инструмента станка и предупреждаем оператора об изношенном инструменте
+	if data.Machine != "" && total > 0 {
+		tools, err := models.ConsumeToolLife(srv, cfg, data.Machine, data.PartAndOperation, total)
+		if err != nil {
+			log.Printf("Error updating tool life: %v", err)
+		}
+		for _, t := range tools {
+			if t.NearEndOfLife(cfg.ToolWarnPercent) {
+				warnings = append(warnings, toolWarning(t))
+			}
+		}
+	}
+
+	// На первой операции маршрута списываем материал по спецификации детали (все обработанные детали,
+	// включая брак), после последней операции годные детали поступают на склад готовой продукции
+	if total > 0 {
+		part, first, last, err := routeStep(srv, cfg, data.PartAndOperation)
+		if err != nil {
+			log.Printf("Error reading operations: %v", err)
+		}
+		if first {
+			warnings = append(warnings, consumeMaterials(srv, cfg, part, total)...)
+		}
+		if good := goodParts(data, total); last && good > 0 {
+			date, _ := utils.ParseDate(data.Date)
+			movement := models.StockMovement{
+				Date:     date,
+				Part:     part,
+				Quantity: good,
+				Document: fmt.Sprintf("Выпуск, строка %d", row),
+				FullName: strings.TrimSpace(data.FullName),
+			}
+			if err := models.ReceiveFinishedGoods(srv, cfg, movement); err != nil {
+				log.Printf("Error receiving finished goods: %v", err)
+			}
+		}
+	}
+	return row, warnings, nil
+}
+
+// previewWarnings проверяет без списания, какие предупреждения об износе инструмента и остатке материала
+// даст запись после утверждения. Вызывается для записей, ожидающих мастера, чтобы оператор увидел их сразу
+func previewWarnings(srv *sheets.Service, cfg config.Config, data models.ProductionData) []string {
+	warnings := make([]string, 0)
+	total, err := strconv.Atoi(strings.TrimSpace(data.TotalParts))
+	if err != nil || total <= 0 {
+		return warnings
+	}
+
+	if data.Machine != "" {
+		tools, err := models.ReadTools(srv, cfg)
+		if err != nil {
+			log.Printf("Error reading tools: %v", err)
+		}
+		for _, t := range tools {
+			if !t.Applies(data.Machine, data.PartAndOperation) {
+				continue
+			}
+			t.Remaining -= total
+			if t.NearEndOfLife(cfg.ToolWarnPercent) {
+				warnings = append(warnings, toolWarning(t))
+			}
+		}
+	}
+
+	part, first, _, err := routeStep(srv, cfg, data.PartAndOperation)
+	if err != nil {
+		log.Printf("Error reading operations: %v", err)
+	}
+	if first {
+		warnings = append(warnings, previewMaterials(srv, cfg, part, total)...)
+	}
+	return warnings
+}
+
+// routeStep определяет деталь, к маршруту которой относится операция, и положение операции в маршруте
+// Если операция не входит в маршрут, возвращает пустую деталь
+func routeStep(srv *sheets.Service, cfg config.Config, operation string) (part string, first, last bool, err error) {
+	operations, err := models.ReadOperations(srv, cfg)
+	if err != nil {
+		return "", false, false, err
+	}
+	op, ok := models.OperationsByName(operations)[operation]
+	if !ok || op.Part == "" {
+		return "", false, false, nil
+	}
+	route := models.Routings(operations)[op.Part]
+	if len(route) == 0 {
+		return "", false, false, nil // У операции нет номера в маршруте
+	}
+	return op.Part, route[0].Name == op.Name, route[len(route)-1].Name == op.Name, nil
+}
+
+// goodParts возвращает количество годных деталей записи о выпуске
+// Если годные не указаны, они считаются как разность всех и бракованных деталей
+func goodParts(data models.ProductionData, total int) int {
+	if good, err := strconv.Atoi(strings.TrimSpace(data.GoodParts)); err == nil {
+		return good
 	}
+	defective, _ := strconv.Atoi(strings.TrimSpace(data.Defective))
+	return total - defective
 }
@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/sergekovalev/siberia/internal/config"
+	"github.com/sergekovalev/siberia/internal/models"
+)
+
+// QualificationsHandler обрабатывает запросы к матрице квалификации
+// GET возвращает допуски (параметры employee и operation фильтруют список),
+// POST добавляет или обновляет допуск: {"employee", "operation", "level", "expires"}
+func QualificationsHandler(srv *sheets.Service, cfg config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			qualifications, err := models.ReadQualifications(srv, cfg)
+			if err != nil {
+				log.Printf("Error reading qualifications: %v", err)
+				http.Error(w, "Failed to read qualifications", http.StatusInternalServerError)
+				return
+			}
+
+			employee := r.URL.Query().Get("employee")
+			operation := r.URL.Query().Get("operation")
+			result := make([]models.Qualification, 0, len(qualifications))
+			for _, q := range qualifications {
+				if (employee == "" || q.Employee == employee) && (operation == "" || q.Operation == operation) {
+					result = append(result, q)
+				}
+			}
+			writeJSON(w, http.StatusOK, result)
+
+		case http.MethodPost:
+			var q models.Qualification
+			if !decodeJSON(w, r, &q) {
+				return
+			}
+
+			// Проверяем обязательные поля
+			q.Employee = strings.TrimSpace(q.Employee)
+			q.Operation = strings.TrimSpace(q.Operation)
+			q.Expires = strings.TrimSpace(q.Expires)
+			if q.Employee == "" || q.Operation == "" || q.Level <= 0 {
+				http.Error(w, "Employee, operation and positive level are required", http.StatusBadRequest)
+				return
+			}
+			if q.Expires != "" && !validDate(q.Expires) {
+				http.Error(w, "Invalid expiry date, expected YYYY-MM-DD", http.StatusBadRequest)
+				return
+			}
+
+			if err := models.SaveQualification(srv, cfg, q); err != nil {
+				log.Printf("Error saving qualification: %v", err)
+				http.Error(w, "Failed to save qualification", http.StatusInternalServerError)
+				return
+			}
+			writeJSON(w, http.StatusOK, map[string]string{"status": "success"})
+
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// QualificationsExpiringHandler возвращает допуски, срок которых истекает в ближайшие дни или уже истек
+// Параметр: days - горизонт в днях (по умолчанию 30)
+func QualificationsExpiringHandler(srv *sheets.Service, cfg config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		days := 30
+		if v := r.URL.Query().Get("days"); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				http.Error(w, "Invalid 'days', expected non-negative number", http.StatusBadRequest)
+				return
+			}
+			days = n
+		}
+
+		qualifications, err := models.ReadQualifications(srv, cfg)
+		if err != nil {
+			log.Printf("Error reading qualifications: %v", err)
+			http.Error(w, "Failed to read qualifications", http.StatusInternalServerError)
+			return
+		}
+
+		type expiring struct {
+			models.Qualification
+			DaysLeft int  `json:"daysLeft"` // Дней до окончания срока (отрицательное значение - срок истек)
+			Expired  bool `json:"expired"`  // Срок допуска истек
+		}
+		now := today()
+		result := make([]expiring, 0)
+		for _, q := range qualifications {
+			if q.Expires == "" {
+				continue // Бессрочный допуск
+			}
+			expires, err := time.Parse("2006-01-02", q.Expires)
+			if err != nil {
+				log.Printf("Invalid qualification expiry in row %d: %q", q.Row, q.Expires)
+				continue
+			}
+			left := int(expires.Sub(now).Hours() / 24)
+			if left > days {
+				continue
+			}
+			result = append(result, expiring{Qualification: q, DaysLeft: left, Expired: !q.ValidOn(now)})
+		}
+		sort.SliceStable(result, func(i, j int) bool { return result[i].DaysLeft < result[j].DaysLeft })
+		writeJSON(w, http.StatusOK, result)
+	}
+}
+
+// checkQualification проверяет допуск сотрудника к операции на дату записи о выпуске
+// Возвращает описание нарушения или пустую строку, если сотрудник допущен
+func checkQualification(qualifications []models.Qualification, minLevel int, employee, operation string, date time.Time) string {
+	q := models.FindQualification(qualifications, employee, operation)
+	switch {
+	case q == nil:
+		return fmt.Sprintf("Сотрудник %s не допущен к операции %s", employee, operation)
+	case !q.ValidOn(date):
+		return fmt.Sprintf("Допуск сотрудника %s к операции %s истек %s", employee, operation, q.Expires)
+	case q.Level < minLevel:
+		return fmt.Sprintf("Уровень квалификации сотрудника %s на операции %s (%d) ниже требуемого (%d)", employee, operation, q.Level, minLevel)
+	}
+	return ""
+}
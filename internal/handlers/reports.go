@@ -0,0 +1,204 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/sergekovalev/siberia/internal/config"
+	"github.com/sergekovalev/siberia/internal/models"
+	"github.com/sergekovalev/siberia/internal/reports"
+)
+
+// EfficiencyReportHandler возвращает отчет о выработке сотрудников и операций за период
+// Параметры: from, to (YYYY-MM-DD), group (day, week, month)
+// Лист табеля вмещает один месяц, поэтому период должен лежать внутри одного месяца, и для этого месяца
+// должен быть лист табеля: текущий месяц или копия табеля прошедшего месяца (иначе 400)
+func EfficiencyReportHandler(srv *sheets.Service, cfg config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		from, to, group, err := parsePeriod(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		// Лист табеля вмещает один месяц: часы берутся из табеля месяца, в который входит весь период
+		if !sameMonth(from, to) {
+			http.Error(w, "Efficiency report period must be within one month", http.StatusBadRequest)
+			return
+		}
+
+		records, err := models.ReadProductionData(srv, cfg)
+		if err != nil {
+			log.Printf("Error reading production data: %v", err)
+			http.Error(w, "Failed to read production data", http.StatusInternalServerError)
+			return
+		}
+
+		operations, err := models.ReadOperations(srv, cfg)
+		if err != nil {
+			log.Printf("Error reading operations: %v", err)
+			http.Error(w, "Failed to read operations", http.StatusInternalServerError)
+			return
+		}
+
+		hours, err := models.ReadTimesheetHours(srv, cfg, from)
+		if errors.Is(err, models.ErrTimesheetNotFound) {
+			sheet := models.TimesheetSheetFor(cfg, from, time.Now())
+			http.Error(w, fmt.Sprintf("No timesheet for %s: sheet %q not found", from.Format("2006-01"), sheet), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			log.Printf("Error reading timesheet: %v", err)
+			http.Error(w, "Failed to read timesheet", http.StatusInternalServerError)
+			return
+		}
+
+		report := reports.Efficiency(records, hours, models.OperationsByName(operations), from, to, group)
+		writeJSON(w, http.StatusOK, report)
+	}
+}
+
+// DefectReportHandler возвращает отчет о браке по операциям с учетом доработки
+// Параметры: from, to (YYYY-MM-DD), group (day, week, month)
+func DefectReportHandler(srv *sheets.Service, cfg config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		from, to, group, err := parsePeriod(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		records, err := models.ReadProductionData(srv, cfg)
+		if err != nil {
+			log.Printf("Error reading production data: %v", err)
+			http.Error(w, "Failed to read production data", http.StatusInternalServerError)
+			return
+		}
+
+		rework, err := models.ReadReworkData(srv, cfg)
+		if err != nil {
+			log.Printf("Error reading rework data: %v", err)
+			http.Error(w, "Failed to read rework data", http.StatusInternalServerError)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, reports.Defects(records, rework, from, to, group))
+	}
+}
+
+// MachineReportHandler возвращает выпуск и долю брака по станкам
+// Параметры: from, to (YYYY-MM-DD), group (day, week, month)
+func MachineReportHandler(srv *sheets.Service, cfg config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		from, to, group, err := parsePeriod(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		records, err := models.ReadProductionData(srv, cfg)
+		if err != nil {
+			log.Printf("Error reading production data: %v", err)
+			http.Error(w, "Failed to read production data", http.StatusInternalServerError)
+			return
+		}
+
+		machines, err := models.ReadMachines(srv, cfg)
+		if err != nil {
+			log.Printf("Error reading machines: %v", err)
+			http.Error(w, "Failed to read machines", http.StatusInternalServerError)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, reports.MachineOutput(records, machines, from, to, group))
+	}
+}
+
+// DefectReasonsHandler возвращает настроенные коды причин брака
+func DefectReasonsHandler(cfg config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		writeJSON(w, http.StatusOK, cfg.DefectReasons)
+	}
+}
+
+// ScrapCostReportHandler возвращает стоимость брака за период
+// Параметры: from, to (YYYY-MM-DD), group (day, week, month), by (part, operation, employee, reason)
+func ScrapCostReportHandler(srv *sheets.Service, cfg config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		from, to, group, err := parsePeriod(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		by := r.URL.Query().Get("by")
+		if by == "" {
+			by = reports.ScrapByPart
+		}
+		if !reports.ValidScrapDimension(by) {
+			http.Error(w, "Invalid 'by', expected part, operation, employee or reason", http.StatusBadRequest)
+			return
+		}
+
+		in := reports.ScrapCostInput{DefectReasons: cfg.DefectReasons, LabourRate: cfg.LabourRate}
+		if in.Records, err = models.ReadProductionData(srv, cfg); err != nil {
+			log.Printf("Error reading production data: %v", err)
+			http.Error(w, "Failed to read production data", http.StatusInternalServerError)
+			return
+		}
+		if in.Rework, err = models.ReadReworkData(srv, cfg); err != nil {
+			log.Printf("Error reading rework data: %v", err)
+			http.Error(w, "Failed to read rework data", http.StatusInternalServerError)
+			return
+		}
+		if in.Operations, err = models.ReadOperations(srv, cfg); err != nil {
+			log.Printf("Error reading operations: %v", err)
+			http.Error(w, "Failed to read operations", http.StatusInternalServerError)
+			return
+		}
+		if in.BOM, err = models.ReadBOM(srv, cfg); err != nil {
+			log.Printf("Error reading bill of materials: %v", err)
+			http.Error(w, "Failed to read bill of materials", http.StatusInternalServerError)
+			return
+		}
+		if in.Materials, err = models.ReadMaterials(srv, cfg); err != nil {
+			log.Printf("Error reading materials: %v", err)
+			http.Error(w, "Failed to read materials", http.StatusInternalServerError)
+			return
+		}
+
+		writeJSON(w, http.StatusOK, reports.ScrapCost(in, from, to, group, by))
+	}
+}
+
+// sameMonth проверяет, что даты относятся к одному месяцу одного года
+func sameMonth(a, b time.Time) bool {
+	return a.Year() == b.Year() && a.Month() == b.Month()
+}
@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/sergekovalev/siberia/internal/config"
+	"github.com/sergekovalev/siberia/internal/models"
+	"github.com/sergekovalev/siberia/internal/reports"
+)
+
+// reworkMu сериализует записи о доработке: проверка недоработанного брака и запись должны быть атомарными,
+// иначе два одновременных запроса доработают одни и те же детали дважды
+var reworkMu sync.Mutex
+
+// ReworkHandler обрабатывает запросы к записям о доработке брака
+// GET возвращает записи за период from-to или по партии (параметр lot), POST добавляет запись
+func ReworkHandler(srv *sheets.Service, cfg config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			from, to, _, err := parsePeriod(r)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			lot := r.URL.Query().Get("lot")
+
+			records, err := models.ReadReworkData(srv, cfg)
+			if err != nil {
+				log.Printf("Error reading rework data: %v", err)
+				http.Error(w, "Failed to read rework data", http.StatusInternalServerError)
+				return
+			}
+
+			result := make([]models.ReworkData, 0, len(records))
+			for _, rw := range records {
+				date, _ := time.Parse("2006-01-02", rw.Date)
+				if (lot != "" && rw.Lot == lot) || (lot == "" && reports.InRange(date, from, to)) {
+					result = append(result, rw)
+				}
+			}
+			writeJSON(w, http.StatusOK, result)
+
+		case http.MethodPost:
+			var data models.ReworkData
+			if !decodeJSON(w, r, &data) {
+				return
+			}
+
+			// Оператор вносит записи только от своего имени
+			if name := sessionFullName(r); name != "" {
+				data.FullName = name
+			}
+
+			reworkMu.Lock()
+			defer reworkMu.Unlock()
+
+			if status, err := validateRework(srv, cfg, &data); err != nil {
+				http.Error(w, err.Error(), status)
+				return
+			}
+
+			row, err := models.AppendReworkData(srv, cfg, data)
+			if err != nil {
+				log.Printf("Error writing rework data: %v", err)
+				http.Error(w, "Failed to process data", http.StatusInternalServerError)
+				return
+			}
+			writeJSON(w, http.StatusCreated, map[string]interface{}{"status": "success", "row": row})
+
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// validateRework проверяет запись о доработке и дополняет ее данными исходной записи о производстве
+// Количество доработанных деталей не может превышать брак, который еще не был доработан
+func validateRework(srv *sheets.Service, cfg config.Config, data *models.ReworkData) (int, error) {
+	data.FullName = strings.TrimSpace(data.FullName)
+	data.Lot = strings.TrimSpace(data.Lot)
+	data.PartAndOperation = strings.TrimSpace(data.PartAndOperation)
+
+	// Проверяем обязательные поля и итоги доработки
+	if data.FullName == "" || data.Quantity <= 0 {
+		return http.StatusBadRequest, fmt.Errorf("Full name and positive quantity are required")
+	}
+	if data.Fixed < 0 || data.Scrapped < 0 || data.Fixed+data.Scrapped != data.Quantity {
+		return http.StatusBadRequest, fmt.Errorf("Fixed and scrapped must add up to quantity")
+	}
+	if data.ProductionRow <= 0 && data.Lot == "" {
+		return http.StatusBadRequest, fmt.Errorf("Production row or lot is required")
+	}
+	if data.Date == "" {
+		data.Date = time.Now().Format("2006-01-02")
+	} else if !validDate(data.Date) {
+		return http.StatusBadRequest, fmt.Errorf("Invalid date format, expected YYYY-MM-DD")
+	}
+
+	records, err := models.ReadProductionData(srv, cfg)
+	if err != nil {
+		log.Printf("Error reading production data: %v", err)
+		return http.StatusInternalServerError, fmt.Errorf("Failed to process data")
+	}
+	previous, err := models.ReadReworkData(srv, cfg)
+	if err != nil {
+		log.Printf("Error reading rework data: %v", err)
+		return http.StatusInternalServerError, fmt.Errorf("Failed to process data")
+	}
+	if err := checkRework(data, records, previous); err != nil {
+		return http.StatusBadRequest, err
+	}
+	return http.StatusOK, nil
+}
+
+// checkRework сверяет доработку с записями о производстве и прежними доработками
+// Доработка по строке дополняется операцией и партией исходной записи. Брак строки, входящей в партию,
+// ограничен и недоработанным браком партии на этой операции: доработки по партии могли уже учесть эти детали
+func checkRework(data *models.ReworkData, records []models.ProductionRecord, previous []models.ReworkData) error {
+	if data.ProductionRow > 0 {
+		// Доработка по конкретной записи: операция и партия берутся из исходной записи
+		var source *models.ProductionRecord
+		for i := range records {
+			if records[i].Row == data.ProductionRow {
+				source = &records[i]
+				break
+			}
+		}
+		if source == nil {
+			return fmt.Errorf("Production record not found")
+		}
+		if data.PartAndOperation != "" && data.PartAndOperation != source.PartAndOperation {
+			return fmt.Errorf("Operation does not match the production record")
+		}
+		data.PartAndOperation = source.PartAndOperation
+		if data.Lot == "" {
+			data.Lot = source.Lot
+		}
+
+		available := source.Defective
+		for _, rw := range previous {
+			if rw.ProductionRow == data.ProductionRow {
+				available -= rw.Quantity
+			}
+		}
+		if source.Lot != "" && data.Lot == source.Lot {
+			available = min(available, lotReworkAvailable(records, previous, data.Lot, data.PartAndOperation))
+		}
+		if data.Quantity > available {
+			return fmt.Errorf("Rework quantity exceeds defects not yet reworked (%d)", max(available, 0))
+		}
+		return nil
+	}
+
+	// Доработка по партии: учитываем весь брак партии на указанной операции
+	if data.PartAndOperation == "" {
+		return fmt.Errorf("Part/operation is required for lot rework")
+	}
+	if available := lotReworkAvailable(records, previous, data.Lot, data.PartAndOperation); data.Quantity > available {
+		return fmt.Errorf("Rework quantity exceeds defects not yet reworked (%d)", max(available, 0))
+	}
+	return nil
+}
+
+// lotReworkAvailable возвращает брак партии на операции, который еще не доработан
+// Учитываются доработки и по партии, и по отдельным строкам партии
+func lotReworkAvailable(records []models.ProductionRecord, previous []models.ReworkData, lot, operation string) int {
+	available := 0
+	for _, rec := range records {
+		if rec.Lot == lot && rec.PartAndOperation == operation {
+			available += rec.Defective
+		}
+	}
+	for _, rw := range previous {
+		if rw.Lot == lot && rw.PartAndOperation == operation {
+			available -= rw.Quantity
+		}
+	}
+	return available
+}
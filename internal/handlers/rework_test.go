@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sergekovalev/siberia/internal/models"
+)
+
+func TestCheckRework(t *testing.T) {
+	const operation = "Вал 01 - токарная"
+	records := []models.ProductionRecord{
+		{Row: 2, PartAndOperation: operation, Lot: "P-1", TotalParts: 100, Defective: 5},
+		{Row: 3, PartAndOperation: operation, Lot: "P-1", TotalParts: 100, Defective: 3},
+		{Row: 4, PartAndOperation: operation, TotalParts: 50, Defective: 4},
+		{Row: 5, PartAndOperation: "Вал 01 - фрезерная", Lot: "P-1", TotalParts: 100, Defective: 2},
+	}
+
+	tests := []struct {
+		name     string
+		data     models.ReworkData
+		previous []models.ReworkData
+		wantErr  string
+		wantLot  string
+		wantOp   string
+	}{
+		{
+			name:    "доработка по строке берет операцию и партию из записи",
+			data:    models.ReworkData{ProductionRow: 2, Quantity: 5},
+			wantLot: "P-1",
+			wantOp:  operation,
+		},
+		{
+			name:    "неизвестная строка",
+			data:    models.ReworkData{ProductionRow: 99, Quantity: 1},
+			wantErr: "Production record not found",
+		},
+		{
+			name:    "операция не совпадает с записью",
+			data:    models.ReworkData{ProductionRow: 2, PartAndOperation: "Вал 01 - фрезерная", Quantity: 1},
+			wantErr: "Operation does not match",
+		},
+		{
+			name:    "больше брака строки",
+			data:    models.ReworkData{ProductionRow: 2, Quantity: 6},
+			wantErr: "(5)",
+		},
+		{
+			name:     "учитывается прежняя доработка строки",
+			data:     models.ReworkData{ProductionRow: 2, Quantity: 3},
+			previous: []models.ReworkData{{ProductionRow: 2, Lot: "P-1", PartAndOperation: operation, Quantity: 3}},
+			wantErr:  "(2)",
+		},
+		{
+			name:     "доработка по партии уже учла брак строки",
+			data:     models.ReworkData{ProductionRow: 2, Quantity: 5},
+			previous: []models.ReworkData{{Lot: "P-1", PartAndOperation: operation, Quantity: 6}},
+			wantErr:  "(2)",
+		},
+		{
+			name:     "строка без партии не зависит от доработок по партиям",
+			data:     models.ReworkData{ProductionRow: 4, Quantity: 4},
+			previous: []models.ReworkData{{Lot: "P-1", PartAndOperation: operation, Quantity: 8}},
+			wantOp:   operation,
+		},
+		{
+			name:    "доработка по партии требует операцию",
+			data:    models.ReworkData{Lot: "P-1", Quantity: 1},
+			wantErr: "Part/operation is required",
+		},
+		{
+			name:    "доработка по партии в пределах брака партии",
+			data:    models.ReworkData{Lot: "P-1", PartAndOperation: operation, Quantity: 8},
+			wantLot: "P-1",
+			wantOp:  operation,
+		},
+		{
+			name:     "доработка по партии учитывает доработки строк",
+			data:     models.ReworkData{Lot: "P-1", PartAndOperation: operation, Quantity: 4},
+			previous: []models.ReworkData{{ProductionRow: 2, Lot: "P-1", PartAndOperation: operation, Quantity: 5}},
+			wantErr:  "(3)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := tt.data
+			err := checkRework(&data, records, tt.previous)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("checkRework() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("checkRework() error = %v", err)
+			}
+			if data.Lot != tt.wantLot || data.PartAndOperation != tt.wantOp {
+				t.Errorf("lot = %q, operation = %q, want %q and %q", data.Lot, data.PartAndOperation, tt.wantLot, tt.wantOp)
+			}
+		})
+	}
+}
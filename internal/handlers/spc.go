@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/sergekovalev/siberia/internal/config"
+	"github.com/sergekovalev/siberia/internal/models"
+	"github.com/sergekovalev/siberia/internal/reports"
+)
+
+// SPCHandler возвращает контрольные карты брака в формате JSON
+// Параметры: from, to (YYYY-MM-DD), group (day, week, month), chart (p, u), by (operation, machine),
+// key - одна операция или станок, baselineDays - глубина истории для расчета границ (по умолчанию 90 дней)
+func SPCHandler(srv *sheets.Service, cfg config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		params, err := parseSPCParams(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		charts, err := buildSPC(srv, cfg, params)
+		if err != nil {
+			log.Printf("Error reading production data: %v", err)
+			http.Error(w, "Failed to read production data", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, charts)
+	}
+}
+
+// SPCChartHandler возвращает контрольную карту одной операции или станка в виде SVG
+// Параметры те же, что у SPCHandler; параметр key обязателен
+func SPCChartHandler(srv *sheets.Service, cfg config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		params, err := parseSPCParams(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if params.key == "" {
+			http.Error(w, "Parameter 'key' is required", http.StatusBadRequest)
+			return
+		}
+		charts, err := buildSPC(srv, cfg, params)
+		if err != nil {
+			log.Printf("Error reading production data: %v", err)
+			http.Error(w, "Failed to read production data", http.StatusInternalServerError)
+			return
+		}
+		if len(charts) == 0 {
+			http.Error(w, "No production data for this key", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "image/svg+xml; charset=utf-8")
+		w.Write([]byte(renderSPCChart(charts[0])))
+	}
+}
+
+// spcParams содержит параметры построения контрольных карт
+type spcParams struct {
+	from, to, baselineFrom time.Time
+	group, chart, by, key  string
+}
+
+// parseSPCParams читает параметры контрольной карты из строки запроса
+func parseSPCParams(r *http.Request) (spcParams, error) {
+	var p spcParams
+	var err error
+	if p.from, p.to, p.group, err = parsePeriod(r); err != nil {
+		return p, err
+	}
+
+	query := r.URL.Query()
+	if p.chart = query.Get("chart"); p.chart == "" {
+		p.chart = reports.ChartP
+	}
+	if !reports.ValidChart(p.chart) {
+		return p, fmt.Errorf("invalid 'chart', expected p or u")
+	}
+	if p.by = query.Get("by"); p.by == "" {
+		p.by = reports.SPCByOperation
+	}
+	if !reports.ValidSPCDimension(p.by) {
+		return p, fmt.Errorf("invalid 'by', expected operation or machine")
+	}
+	baselineDays := 90
+	if v := query.Get("baselineDays"); v != "" {
+		if baselineDays, err = strconv.Atoi(v); err != nil || baselineDays < 0 {
+			return p, fmt.Errorf("invalid 'baselineDays', expected non-negative number")
+		}
+	}
+	p.baselineFrom = p.from.AddDate(0, 0, -baselineDays)
+	p.key = query.Get("key")
+	return p, nil
+}
+
+// buildSPC читает записи о выпуске и строит контрольные карты
+func buildSPC(srv *sheets.Service, cfg config.Config, p spcParams) ([]reports.SPCChart, error) {
+	records, err := models.ReadProductionData(srv, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Оставляем записи одной операции или станка, если они заданы
+	if p.key != "" {
+		filtered := records[:0]
+		for _, rec := range records {
+			if (p.by == reports.SPCByMachine && rec.Machine == p.key) || (p.by == reports.SPCByOperation && rec.PartAndOperation == p.key) {
+				filtered = append(filtered, rec)
+			}
+		}
+		records = filtered
+	}
+	return reports.SPC(records, p.baselineFrom, p.from, p.to, p.group, p.by, p.chart), nil
+}
+
+// renderSPCChart формирует SVG-изображение контрольной карты
+// Точки с нарушениями правил Western Electric выделяются красным
+func renderSPCChart(c reports.SPCChart) string {
+	const (
+		width, height = 800, 320
+		left, right   = 60, 20
+		top, bottom   = 40, 60
+	)
+	plotW := float64(width - left - right)
+	plotH := float64(height - top - bottom)
+
+	// Масштаб по вертикали: от нуля до максимума значений и верхних границ с запасом
+	maxY := c.Center
+	for _, p := range c.Points {
+		maxY = math.Max(maxY, math.Max(p.Value, p.UCL))
+	}
+	if maxY == 0 {
+		maxY = 0.01
+	}
+	maxY *= 1.1
+
+	x := func(i int) float64 {
+		if len(c.Points) == 1 {
+			return float64(left) + plotW/2
+		}
+		return float64(left) + plotW*float64(i)/float64(len(c.Points)-1)
+	}
+	y := func(v float64) float64 {
+		return float64(top) + plotH*(1-v/maxY)
+	}
+	label := func(v float64) string {
+		if c.Type == reports.ChartP {
+			return strconv.FormatFloat(v*100, 'f', 1, 64) + "%"
+		}
+		return strconv.FormatFloat(v, 'f', 3, 64)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="Arial, sans-serif" font-size="11">`, width, height, width, height)
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="#fff"/>`, width, height)
+	fmt.Fprintf(&b, `<text x="%d" y="20" font-size="14" fill="#2c3e50">%s-карта: %s</text>`, left, c.Type, html.EscapeString(c.Key))
+
+	// Оси
+	fmt.Fprintf(&b, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="#999"/>`, left, top, left, height-bottom)
+	fmt.Fprintf(&b, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="#999"/>`, left, height-bottom, width-right, height-bottom)
+
+	// Центральная линия
+	fmt.Fprintf(&b, `<line x1="%d" y1="%.1f" x2="%d" y2="%.1f" stroke="#2e7d32"/>`, left, y(c.Center), width-right, y(c.Center))
+	fmt.Fprintf(&b, `<text x="%d" y="%.1f" text-anchor="end" fill="#2e7d32">%s</text>`, left-4, y(c.Center)+4, label(c.Center))
+
+	// Контрольные границы (ступенчатые, так как зависят от объема подгруппы)
+	ucl := make([]string, 0, len(c.Points))
+	lcl := make([]string, 0, len(c.Points))
+	values := make([]string, 0, len(c.Points))
+	for i, p := range c.Points {
+		ucl = append(ucl, fmt.Sprintf("%.1f,%.1f", x(i), y(p.UCL)))
+		lcl = append(lcl, fmt.Sprintf("%.1f,%.1f", x(i), y(p.LCL)))
+		values = append(values, fmt.Sprintf("%.1f,%.1f", x(i), y(p.Value)))
+	}
+	fmt.Fprintf(&b, `<polyline points="%s" fill="none" stroke="#e74c3c" stroke-dasharray="4 3"/>`, strings.Join(ucl, " "))
+	fmt.Fprintf(&b, `<polyline points="%s" fill="none" stroke="#e74c3c" stroke-dasharray="4 3"/>`, strings.Join(lcl, " "))
+	fmt.Fprintf(&b, `<polyline points="%s" fill="none" stroke="#4285f4" stroke-width="2"/>`, strings.Join(values, " "))
+
+	// Точки и подписи периодов (не больше 12 подписей)
+	step := (len(c.Points) + 11) / 12
+	for i, p := range c.Points {
+		color := "#4285f4"
+		if len(p.Violations) > 0 {
+			color = "#e74c3c"
+		}
+		fmt.Fprintf(&b, `<circle cx="%.1f" cy="%.1f" r="4" fill="%s"><title>%s: %s (%d из %d)</title></circle>`,
+			x(i), y(p.Value), color, html.EscapeString(p.Period), label(p.Value), p.Defects, p.Sample)
+		if i%step == 0 {
+			fmt.Fprintf(&b, `<text x="%.1f" y="%d" text-anchor="end" transform="rotate(-45 %.1f %d)" fill="#666">%s</text>`,
+				x(i), height-bottom+14, x(i), height-bottom+14, html.EscapeString(p.Period))
+		}
+	}
+	fmt.Fprintf(&b, `<text x="%d" y="%.1f" text-anchor="end" fill="#e74c3c">UCL</text>`, width-right, y(c.Points[len(c.Points)-1].UCL)-4)
+
+	b.WriteString(`</svg>`)
+	return b.String()
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/sergekovalev/siberia/internal/config"
+	"github.com/sergekovalev/siberia/internal/models"
+	"github.com/sergekovalev/siberia/internal/reports"
+)
+
+// StockHandler возвращает текущие остатки готовой продукции по деталям
+// Параметр: part - остаток одной детали
+func StockHandler(srv *sheets.Service, cfg config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		movements, err := models.ReadStockMovements(srv, cfg)
+		if err != nil {
+			log.Printf("Error reading stock movements: %v", err)
+			http.Error(w, "Failed to read stock", http.StatusInternalServerError)
+			return
+		}
+
+		balances := models.StockBalances(movements)
+		if part := r.URL.Query().Get("part"); part != "" {
+			filtered := make([]models.StockBalance, 0, 1)
+			for _, b := range balances {
+				if b.Part == part {
+					filtered = append(filtered, b)
+				}
+			}
+			balances = filtered
+		}
+		writeJSON(w, http.StatusOK, balances)
+	}
+}
+
+// StockMovementsHandler возвращает журнал движения готовой продукции за период
+// Параметры: from, to (YYYY-MM-DD), part - фильтр по детали, type - production или shipment
+func StockMovementsHandler(srv *sheets.Service, cfg config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		from, to, _, err := parsePeriod(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		movements, err := models.ReadStockMovements(srv, cfg)
+		if err != nil {
+			log.Printf("Error reading stock movements: %v", err)
+			http.Error(w, "Failed to read stock movements", http.StatusInternalServerError)
+			return
+		}
+
+		part := r.URL.Query().Get("part")
+		movementType := r.URL.Query().Get("type")
+		writeJSON(w, http.StatusOK, filterMovements(movements, from, to, func(m models.StockMovement) bool {
+			return (part == "" || m.Part == part) && (movementType == "" || m.Type == movementType)
+		}))
+	}
+}
+
+// ShipmentsHandler обрабатывает запросы к отгрузкам готовой продукции
+// GET возвращает отгрузки за период (параметры from, to, part, customer),
+// POST записывает отгрузку: {"date", "part", "customer", "quantity", "document", "fullName"}
+func ShipmentsHandler(srv *sheets.Service, cfg config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			from, to, _, err := parsePeriod(r)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			movements, err := models.ReadStockMovements(srv, cfg)
+			if err != nil {
+				log.Printf("Error reading stock movements: %v", err)
+				http.Error(w, "Failed to read shipments", http.StatusInternalServerError)
+				return
+			}
+
+			part := r.URL.Query().Get("part")
+			customer := r.URL.Query().Get("customer")
+			writeJSON(w, http.StatusOK, filterMovements(movements, from, to, func(m models.StockMovement) bool {
+				return m.Type == models.MovementShipment && (part == "" || m.Part == part) && (customer == "" || m.Customer == customer)
+			}))
+
+		case http.MethodPost:
+			var req struct {
+				Date     string `json:"date"`
+				Part     string `json:"part"`
+				Customer string `json:"customer"`
+				Quantity int    `json:"quantity"`
+				Document string `json:"document"`
+				FullName string `json:"fullName"`
+			}
+			if !decodeJSON(w, r, &req) {
+				return
+			}
+
+			// Проверяем обязательные поля
+			req.Part = strings.TrimSpace(req.Part)
+			req.Customer = strings.TrimSpace(req.Customer)
+			req.Document = strings.TrimSpace(req.Document)
+			if req.Part == "" || req.Customer == "" || req.Document == "" || req.Quantity <= 0 {
+				http.Error(w, "Part, customer, document number and positive quantity are required", http.StatusBadRequest)
+				return
+			}
+			date := today()
+			if req.Date != "" {
+				var err error
+				if date, err = time.Parse("2006-01-02", req.Date); err != nil {
+					http.Error(w, "Invalid date, expected YYYY-MM-DD", http.StatusBadRequest)
+					return
+				}
+			}
+
+			shipment := models.StockMovement{
+				Date:     date,
+				Part:     req.Part,
+				Quantity: req.Quantity,
+				Document: req.Document,
+				Customer: req.Customer,
+				FullName: strings.TrimSpace(req.FullName),
+			}
+			if err := models.ShipFinishedGoods(srv, cfg, shipment); err != nil {
+				if errors.Is(err, models.ErrInsufficientStock) {
+					http.Error(w, "Not enough finished goods in stock", http.StatusConflict)
+					return
+				}
+				log.Printf("Error writing shipment: %v", err)
+				http.Error(w, "Failed to save shipment", http.StatusInternalServerError)
+				return
+			}
+			writeJSON(w, http.StatusCreated, map[string]string{"status": "success"})
+
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// filterMovements оставляет движения за период from-to, удовлетворяющие условию match
+func filterMovements(movements []models.StockMovement, from, to time.Time, match func(models.StockMovement) bool) []models.StockMovement {
+	result := make([]models.StockMovement, 0)
+	for _, m := range movements {
+		if reports.InRange(m.Date, from, to) && match(m) {
+			result = append(result, m)
+		}
+	}
+	return result
+}
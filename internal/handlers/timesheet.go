@@ -13,10 +13,13 @@ import (
 
 	"github.com/sergekovalev/siberia/internal/config"
 	"github.com/sergekovalev/siberia/internal/models"
+	"github.com/sergekovalev/siberia/internal/store"
 )
 
 // TimesheetHandler обрабатывает HTTP-запросы для добавления данных табеля учета рабочего времени
-func TimesheetHandler(srv *sheets.Service, cfg config.Config) http.HandlerFunc {
+// Часы сотрудника, которым требуется утверждение мастером, сохраняются как ожидающие (ответ 202).
+// Часы за дни закрытого периода не принимаются
+func TimesheetHandler(srv *sheets.Service, cfg config.Config, submissions *store.Collection[models.Submission], periods *store.Collection[models.ClosedPeriod]) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		// Проверяем, что метод запроса - POST
 		if r.Method != http.MethodPost {
@@ -26,31 +29,36 @@ func TimesheetHandler(srv *sheets.Service, cfg config.Config) http.HandlerFunc {
 
 		// Декодируем тело запроса в структуру TimesheetData
 		var data models.TimesheetData
-		if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
-			http.Error(w, "Invalid request body", http.StatusBadRequest) // Ошибка 400, если тело запроса некорректно
+		if !decodeJSON(w, r, &data) {
 			return
 		}
 
-		// Проверяем обязательные поля: FullName и Hours
-		if strings.TrimSpace(data.FullName) == "" || strings.TrimSpace(data.Hours) == "" {
-			http.Error(w, "Full name and hours are required", http.StatusBadRequest) // Ошибка 400, если поля пустые
+		// Оператор вносит записи только от своего имени
+		if name := sessionFullName(r); name != "" {
+			data.FullName = name
+		}
+
+		if problem := validateTimesheet(&data); problem != "" {
+			http.Error(w, problem, http.StatusBadRequest) // Ошибка 400, если запись некорректна
+			return
+		}
+		// Период не должен закрыться между проверкой и записью
+		periodMu.RLock()
+		defer periodMu.RUnlock()
+		if status, problem := checkOpenPeriod(periods, data.Date); problem != "" {
+			http.Error(w, problem, status) // Ошибка 409, если период закрыт
 			return
 		}
 
-		// Если дата не указана, устанавливаем текущую дату
-		if data.Date == "" {
-			data.Date = time.Now().Format("2006-01-02") // Форматируем дату в формате YYYY-MM-DD
-		} else {
-			// Проверяем формат даты
-			if _, err := time.Parse("2006-01-02", data.Date); err != nil {
-				http.Error(w, "Invalid date format, expected YYYY-MM-DD", http.StatusBadRequest) // Ошибка 400, если формат даты некорректен
+		// Часы оператора ждут утверждения мастером
+		if needsApproval(r, cfg) {
+			submission, err := submit(r, submissions, models.Submission{Kind: models.SubmissionTimesheet, Timesheet: &data})
+			if err != nil {
+				log.Printf("Error saving submission: %v", err)
+				http.Error(w, "Failed to process data", http.StatusInternalServerError)
 				return
 			}
-		}
-
-		// Проверяем, что поле Hours содержит число
-		if _, err := strconv.ParseFloat(data.Hours, 64); err != nil {
-			http.Error(w, "Hours must be a number", http.StatusBadRequest) // Ошибка 400, если поле Hours не является числом
+			writeJSON(w, http.StatusAccepted, map[string]string{"status": models.SubmissionPending, "id": submission.ID})
 			return
 		}
 
@@ -66,3 +74,25 @@ func TimesheetHandler(srv *sheets.Service, cfg config.Config) http.HandlerFunc {
 		json.NewEncoder(w).Encode(map[string]string{"status": "success"}) // Отправляем JSON-ответ с сообщением об успехе
 	}
 }
+
+// validateTimesheet проверяет запись табеля и подставляет текущую дату, если дата не указана
+// Возвращает описание проблемы или пустую строку
+func validateTimesheet(data *models.TimesheetData) string {
+	// Проверяем обязательные поля: FullName и Hours
+	if strings.TrimSpace(data.FullName) == "" || strings.TrimSpace(data.Hours) == "" {
+		return "Full name and hours are required"
+	}
+
+	// Если дата не указана, устанавливаем текущую дату
+	if data.Date == "" {
+		data.Date = time.Now().Format("2006-01-02") // Форматируем дату в формате YYYY-MM-DD
+	} else if _, err := time.Parse("2006-01-02", data.Date); err != nil {
+		return "Invalid date format, expected YYYY-MM-DD"
+	}
+
+	// Проверяем, что поле Hours содержит число
+	if _, err := strconv.ParseFloat(data.Hours, 64); err != nil {
+		return "Hours must be a number"
+	}
+	return ""
+}
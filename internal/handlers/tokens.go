@@ -0,0 +1,99 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/sergekovalev/siberia/internal/auth"
+	"github.com/sergekovalev/siberia/internal/models"
+	"github.com/sergekovalev/siberia/internal/store"
+)
+
+// TokensHandler обрабатывает запросы к API-токенам станков и скриптов
+// GET возвращает токены (без секретов, новые - первыми), POST создает токен: {"name", "permissions": ["production:write", ...], "device"}.
+// Созданный токен возвращается в поле token только один раз
+func TokensHandler(am *auth.Manager, audit *store.Collection[models.AuditEntry]) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			tokens := am.Tokens()
+			sort.Slice(tokens, func(i, j int) bool { return tokens[i].Created.After(tokens[j].Created) })
+			writeJSON(w, http.StatusOK, tokens)
+
+		case http.MethodPost:
+			var req struct {
+				Name        string   `json:"name"`
+				Permissions []string `json:"permissions"`
+				Device      string   `json:"device"`
+			}
+			if !decodeJSON(w, r, &req) {
+				return
+			}
+			if strings.TrimSpace(req.Name) == "" || len(req.Permissions) == 0 {
+				http.Error(w, "Name and permissions are required", http.StatusBadRequest)
+				return
+			}
+
+			u, _ := auth.UserFromContext(r.Context())
+			token, t, err := am.CreateToken(req.Name, req.Permissions, req.Device, u.FullName)
+			if errors.Is(err, auth.ErrInvalidPermission) {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if errors.Is(err, auth.ErrDeviceExists) {
+				http.Error(w, err.Error(), http.StatusConflict)
+				return
+			}
+			if err != nil {
+				log.Printf("Error saving API token: %v", err)
+				http.Error(w, "Failed to create token", http.StatusInternalServerError)
+				return
+			}
+			if err := writeAudit(r, audit, models.AuditTokenCreate, fmt.Sprintf("%s (%s): %s", t.Name, t.Hint, strings.Join(t.Permissions, ", "))); err != nil {
+				log.Printf("Error writing audit log: %v", err)
+			}
+			log.Printf("API token %s created by %s", t.Name, u.Username)
+			writeJSON(w, http.StatusCreated, map[string]interface{}{"token": token, "info": t})
+
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// TokenRevokeHandler отзывает API-токен: POST {"id"}
+func TokenRevokeHandler(am *auth.Manager, audit *store.Collection[models.AuditEntry]) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req struct {
+			ID string `json:"id"`
+		}
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+
+		t, found, err := am.RevokeToken(req.ID)
+		if err != nil {
+			log.Printf("Error revoking API token: %v", err)
+			http.Error(w, "Failed to revoke token", http.StatusInternalServerError)
+			return
+		}
+		if !found {
+			http.Error(w, "Token not found", http.StatusNotFound)
+			return
+		}
+		if err := writeAudit(r, audit, models.AuditTokenRevoke, fmt.Sprintf("%s (%s)", t.Name, t.Hint)); err != nil {
+			log.Printf("Error writing audit log: %v", err)
+		}
+		log.Printf("API token %s revoked", t.Name)
+		writeJSON(w, http.StatusOK, t)
+	}
+}
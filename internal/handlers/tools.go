@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/sergekovalev/siberia/internal/config"
+	"github.com/sergekovalev/siberia/internal/models"
+)
+
+// ToolsHandler обрабатывает запросы к реестру режущего инструмента
+// GET возвращает инструмент (параметр machine фильтрует по станку), POST регистрирует новый инструмент
+func ToolsHandler(srv *sheets.Service, cfg config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			tools, err := readToolsForMachine(srv, cfg, r.URL.Query().Get("machine"))
+			if err != nil {
+				log.Printf("Error reading tools: %v", err)
+				http.Error(w, "Failed to read tools", http.StatusInternalServerError)
+				return
+			}
+			writeJSON(w, http.StatusOK, tools)
+
+		case http.MethodPost:
+			var t models.Tool
+			if !decodeJSON(w, r, &t) {
+				return
+			}
+
+			// Проверяем обязательные поля
+			t.ID = strings.TrimSpace(t.ID)
+			t.Name = strings.TrimSpace(t.Name)
+			t.Machine = strings.TrimSpace(t.Machine)
+			t.Operation = strings.TrimSpace(t.Operation)
+			if t.ID == "" || t.Name == "" || t.Machine == "" || t.ExpectedLife <= 0 {
+				http.Error(w, "Tool id, name, machine and positive expected life are required", http.StatusBadRequest)
+				return
+			}
+
+			machine, err := models.FindMachine(srv, cfg, t.Machine)
+			if err != nil {
+				log.Printf("Error reading machines: %v", err)
+				http.Error(w, "Failed to save tool", http.StatusInternalServerError)
+				return
+			}
+			if machine == nil {
+				http.Error(w, "Unknown machine", http.StatusBadRequest)
+				return
+			}
+
+			existing, err := models.FindTool(srv, cfg, t.ID)
+			if err != nil {
+				log.Printf("Error reading tools: %v", err)
+				http.Error(w, "Failed to save tool", http.StatusInternalServerError)
+				return
+			}
+			if existing != nil {
+				http.Error(w, "Tool with this id already exists", http.StatusConflict)
+				return
+			}
+
+			// Новый инструмент устанавливается с полной стойкостью
+			t.Remaining = t.ExpectedLife
+			t.Installed = time.Now()
+			if err := models.AppendTool(srv, cfg, t); err != nil {
+				log.Printf("Error writing tool: %v", err)
+				http.Error(w, "Failed to save tool", http.StatusInternalServerError)
+				return
+			}
+			writeJSON(w, http.StatusCreated, map[string]string{"status": "success"})
+
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// Тело запроса: {"id", "reason", "fullName"}; сотрудником записывается вошедший пользователь, fullName - только если в сессии нет ФИО
+// Тело запроса: {"id", "reason", "fullName"}
+func ToolChangeHandler(srv *sheets.Service, cfg config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req struct {
+			ID       string `json:"id"`
+			Reason   string `json:"reason"`
+			FullName string `json:"fullName"`
+		}
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+		if req.Reason = strings.TrimSpace(req.Reason); req.Reason == "" {
+			req.Reason = "Износ" // Причина по умолчанию
+		}
+
+		// Замену записывает вошедший сотрудник; fullName учитывается, только если в сессии нет ФИО
+		err := models.ChangeTool(srv, cfg, strings.TrimSpace(req.ID), req.Reason, authorName(r, req.FullName))
+		if errors.Is(err, models.ErrToolNotFound) {
+			http.Error(w, "Tool not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			log.Printf("Error changing tool: %v", err)
+			http.Error(w, "Failed to process data", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"status": "success"})
+	}
+}
+
+// ToolAlertsHandler возвращает инструмент, близкий к концу стойкости
+// Параметр: machine - фильтр по станку
+func ToolAlertsHandler(srv *sheets.Service, cfg config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		tools, err := readToolsForMachine(srv, cfg, r.URL.Query().Get("machine"))
+		if err != nil {
+			log.Printf("Error reading tools: %v", err)
+			http.Error(w, "Failed to read tools", http.StatusInternalServerError)
+			return
+		}
+
+		type alert struct {
+			models.Tool
+			Message string `json:"message"`
+		}
+		alerts := make([]alert, 0)
+		for _, t := range tools {
+			if t.NearEndOfLife(cfg.ToolWarnPercent) {
+				alerts = append(alerts, alert{Tool: t, Message: toolWarning(t)})
+			}
+		}
+		writeJSON(w, http.StatusOK, alerts)
+	}
+}
+
+// readToolsForMachine читает реестр инструмента и оставляет инструмент указанного станка (если он задан)
+func readToolsForMachine(srv *sheets.Service, cfg config.Config, machine string) ([]models.Tool, error) {
+	tools, err := models.ReadTools(srv, cfg)
+	if err != nil || machine == "" {
+		return tools, err
+	}
+	filtered := tools[:0]
+	for _, t := range tools {
+		if t.Machine == machine {
+			filtered = append(filtered, t)
+		}
+	}
+	return filtered, nil
+}
+
+// toolWarning формирует предупреждение оператору об остатке стойкости инструмента
+func toolWarning(t models.Tool) string {
+	if t.Remaining <= 0 {
+		return fmt.Sprintf("Инструмент %s (%s) на станке %s выработал ресурс: замените инструмент", t.Name, t.ID, t.Machine)
+	}
+	return fmt.Sprintf("Инструмент %s (%s) на станке %s: осталось %d из %d деталей", t.Name, t.ID, t.Machine, t.Remaining, t.ExpectedLife)
+}
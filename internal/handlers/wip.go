@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/sergekovalev/siberia/internal/config"
+	"github.com/sergekovalev/siberia/internal/models"
+	"github.com/sergekovalev/siberia/internal/reports"
+)
+
+// WIPHandler возвращает незавершенное производство по маршрутам деталей
+// Параметры: part - деталь, lot - партия. Без параметра lot учитываются только незакрытые партии,
+// а записи без партии - только за период from-to (по умолчанию текущий месяц)
+func WIPHandler(srv *sheets.Service, cfg config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		from, to, _, err := parsePeriod(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		part := r.URL.Query().Get("part")
+		lot := r.URL.Query().Get("lot")
+
+		operations, err := models.ReadOperations(srv, cfg)
+		if err != nil {
+			log.Printf("Error reading operations: %v", err)
+			http.Error(w, "Failed to read operations", http.StatusInternalServerError)
+			return
+		}
+
+		orders, err := models.ReadWorkOrders(srv, cfg)
+		if err != nil {
+			log.Printf("Error reading work orders: %v", err)
+			http.Error(w, "Failed to read work orders", http.StatusInternalServerError)
+			return
+		}
+
+		records, err := models.ReadProductionData(srv, cfg)
+		if err != nil {
+			log.Printf("Error reading production data: %v", err)
+			http.Error(w, "Failed to read production data", http.StatusInternalServerError)
+			return
+		}
+
+		// Закрытые партии не попадают на доску, если партия не запрошена явно
+		closed := make(map[string]bool)
+		for _, wo := range orders {
+			if !wo.Active() {
+				closed[wo.Number] = true
+			}
+		}
+
+		selected := make([]models.ProductionRecord, 0, len(records))
+		for _, rec := range records {
+			switch {
+			case lot != "":
+				if rec.Lot != lot {
+					continue
+				}
+			case rec.Lot == "":
+				if !reports.InRange(rec.Date, from, to) {
+					continue
+				}
+			case closed[rec.Lot]:
+				continue
+			}
+			selected = append(selected, rec)
+		}
+
+		routes := reports.WIP(operations, selected, orders)
+		if part != "" {
+			filtered := routes[:0]
+			for _, route := range routes {
+				if route.Part == part {
+					filtered = append(filtered, route)
+				}
+			}
+			routes = filtered
+		}
+		writeJSON(w, http.StatusOK, routes)
+	}
+}
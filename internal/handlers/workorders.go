@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/sergekovalev/siberia/internal/config"
+	"github.com/sergekovalev/siberia/internal/models"
+	"github.com/sergekovalev/siberia/internal/reports"
+)
+
+// workOrderMu сериализует создание заказов: проверка уникальности номера и запись должны быть атомарными
+var workOrderMu sync.Mutex
+
+// WorkOrdersHandler обрабатывает запросы к заказам (партиям)
+// GET возвращает заказы (параметр status фильтрует по статусу), POST создает заказ
+func WorkOrdersHandler(srv *sheets.Service, cfg config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			orders, err := models.ReadWorkOrders(srv, cfg)
+			if err != nil {
+				log.Printf("Error reading work orders: %v", err)
+				http.Error(w, "Failed to read work orders", http.StatusInternalServerError)
+				return
+			}
+
+			// Фильтруем по статусу, если он указан
+			if status := r.URL.Query().Get("status"); status != "" {
+				filtered := orders[:0]
+				for _, wo := range orders {
+					if wo.Status == status {
+						filtered = append(filtered, wo)
+					}
+				}
+				orders = filtered
+			}
+			writeJSON(w, http.StatusOK, orders)
+
+		case http.MethodPost:
+			var wo models.WorkOrder
+			if !decodeJSON(w, r, &wo) {
+				return
+			}
+
+			// Проверяем обязательные поля
+			wo.Number = strings.TrimSpace(wo.Number)
+			wo.Part = strings.TrimSpace(wo.Part)
+			if wo.Number == "" || wo.Part == "" || wo.Quantity <= 0 {
+				http.Error(w, "Number, part and positive quantity are required", http.StatusBadRequest)
+				return
+			}
+			if wo.DueDate != "" && !validDate(wo.DueDate) {
+				http.Error(w, "Invalid due date format, expected YYYY-MM-DD", http.StatusBadRequest)
+				return
+			}
+			if wo.Status != "" && !models.ValidWorkOrderStatus(wo.Status) {
+				http.Error(w, "Invalid status", http.StatusBadRequest)
+				return
+			}
+
+			// Номер заказа должен быть уникальным, так как используется как номер партии;
+			// проверка и запись выполняются под workOrderMu, чтобы два запроса не создали один номер
+			workOrderMu.Lock()
+			defer workOrderMu.Unlock()
+
+			existing, err := models.FindWorkOrder(srv, cfg, wo.Number)
+			if err != nil {
+				log.Printf("Error reading work orders: %v", err)
+				http.Error(w, "Failed to read work orders", http.StatusInternalServerError)
+				return
+			}
+			if existing != nil {
+				http.Error(w, "Work order with this number already exists", http.StatusConflict)
+				return
+			}
+
+			if err := models.AppendWorkOrder(srv, cfg, wo); err != nil {
+				log.Printf("Error writing work order: %v", err)
+				http.Error(w, "Failed to save work order", http.StatusInternalServerError)
+				return
+			}
+			writeJSON(w, http.StatusCreated, map[string]string{"status": "success"})
+
+		default:
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// WorkOrderStatusHandler меняет статус заказа
+// Тело запроса: {"number": "...", "status": "open|in_progress|completed|cancelled"}
+func WorkOrderStatusHandler(srv *sheets.Service, cfg config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var req struct {
+			Number string `json:"number"`
+			Status string `json:"status"`
+		}
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+		if !models.ValidWorkOrderStatus(req.Status) {
+			http.Error(w, "Invalid status", http.StatusBadRequest)
+			return
+		}
+
+		wo, err := models.FindWorkOrder(srv, cfg, strings.TrimSpace(req.Number))
+		if err != nil {
+			log.Printf("Error reading work orders: %v", err)
+			http.Error(w, "Failed to read work orders", http.StatusInternalServerError)
+			return
+		}
+		if wo == nil {
+			http.Error(w, "Work order not found", http.StatusNotFound)
+			return
+		}
+
+		if err := models.UpdateWorkOrderStatus(srv, cfg, *wo, req.Status); err != nil {
+			log.Printf("Error updating work order: %v", err)
+			http.Error(w, "Failed to update work order", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]string{"status": "success"})
+	}
+}
+
+// LotTraceHandler возвращает историю изготовления партии: операции, сотрудников, даты и брак
+// Параметр: lot - номер партии
+func LotTraceHandler(srv *sheets.Service, cfg config.Config) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		lot := strings.TrimSpace(r.URL.Query().Get("lot"))
+		if lot == "" {
+			http.Error(w, "Lot number is required", http.StatusBadRequest)
+			return
+		}
+
+		wo, err := models.FindWorkOrder(srv, cfg, lot)
+		if err != nil {
+			log.Printf("Error reading work orders: %v", err)
+			http.Error(w, "Failed to read work orders", http.StatusInternalServerError)
+			return
+		}
+
+		records, err := models.ReadProductionData(srv, cfg)
+		if err != nil {
+			log.Printf("Error reading production data: %v", err)
+			http.Error(w, "Failed to read production data", http.StatusInternalServerError)
+			return
+		}
+
+		trace := reports.TraceLot(lot, wo, records)
+		if wo == nil && len(trace.Entries) == 0 {
+			http.Error(w, "Lot not found", http.StatusNotFound)
+			return
+		}
+		writeJSON(w, http.StatusOK, trace)
+	}
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// Действия, которые записываются в журнал аудита
+const (
+	AuditPeriodClose  = "period.close"  // Закрытие периода
+	AuditPeriodReopen = "period.reopen" // Открытие закрытого периода
+	AuditTokenCreate  = "token.create"  // Создание API-токена
+	AuditTokenRevoke  = "token.revoke"  // Отзыв API-токена
+)
+
+// AuditEntry представляет запись журнала аудита административных действий
+// Журнал хранится в каталоге данных приложения и только дополняется
+type AuditEntry struct {
+	ID      string    `json:"id"`      // Идентификатор записи
+	Time    time.Time `json:"time"`    // Время действия
+	UserID  string    `json:"userId"`  // Идентификатор пользователя
+	User    string    `json:"user"`    // ФИО пользователя
+	Action  string    `json:"action"`  // Действие, например period.reopen
+	Details string    `json:"details"` // Подробности: период, причина и т.п.
+}
@@ -0,0 +1,103 @@
+package models
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/sergekovalev/siberia/internal/config"
+	"github.com/sergekovalev/siberia/internal/utils"
+)
+
+// dateTimeFormat - формат даты и времени при записи в таблицу
+const dateTimeFormat = "2006-01-02 15:04:05"
+
+// Downtime представляет простой оборудования
+// Лист простоев: A - идентификатор, B - станок, C - код причины, D - начало, E - окончание,
+// F - примечания, G - сотрудник, зафиксировавший простой
+type Downtime struct {
+	Row      int        `json:"row,omitempty"` // Номер строки на листе простоев
+	ID       string     `json:"id"`            // Идентификатор простоя
+	Machine  string     `json:"machine"`       // Идентификатор станка
+	Reason   string     `json:"reason"`        // Код причины простоя
+	Start    time.Time  `json:"start"`         // Начало простоя
+	End      *time.Time `json:"end,omitempty"` // Окончание простоя (nil, пока простой не завершен)
+	Notes    string     `json:"notes"`         // Примечания
+	FullName string     `json:"fullName"`      // Сотрудник, зафиксировавший простой
+}
+
+// Open проверяет, что простой еще не завершен
+func (d Downtime) Open() bool {
+	return d.End == nil
+}
+
+// ReadDowntime читает журнал простоев
+func ReadDowntime(srv *sheets.Service, cfg config.Config) ([]Downtime, error) {
+	rows, err := readRows(srv, cfg.SpreadsheetID, sheetRange(cfg.DowntimeSheet, "A2:G"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read downtime: %v", err)
+	}
+
+	events := make([]Downtime, 0, len(rows))
+	for i, row := range rows {
+		if cell(row, 0) == "" {
+			continue // Пропускаем пустые строки
+		}
+
+		start, err := utils.ParseDateTime(cell(row, 3))
+		if err != nil {
+			log.Printf("Skipping downtime row %d: %v", i+2, err)
+			continue
+		}
+
+		d := Downtime{
+			Row:      i + 2,
+			ID:       cell(row, 0),
+			Machine:  cell(row, 1),
+			Reason:   cell(row, 2),
+			Start:    start,
+			Notes:    cell(row, 5),
+			FullName: cell(row, 6),
+		}
+		if value := cell(row, 4); value != "" {
+			end, err := utils.ParseDateTime(value)
+			if err != nil {
+				log.Printf("Skipping downtime row %d: %v", i+2, err)
+				continue
+			}
+			d.End = &end
+		}
+		events = append(events, d)
+	}
+	return events, nil
+}
+
+// AppendDowntime добавляет простой в журнал
+func AppendDowntime(srv *sheets.Service, cfg config.Config, d Downtime) error {
+	row, err := appendRow(srv, cfg.SpreadsheetID, cfg.DowntimeSheet, downtimeValues(d))
+	if err != nil {
+		return err
+	}
+	log.Printf("Downtime %s written to row %d", d.ID, row)
+	return nil
+}
+
+// UpdateDowntime перезаписывает строку простоя (например, при его завершении)
+func UpdateDowntime(srv *sheets.Service, cfg config.Config, d Downtime) error {
+	if err := updateRow(srv, cfg.SpreadsheetID, cfg.DowntimeSheet, d.Row, downtimeValues(d)); err != nil {
+		return err
+	}
+	log.Printf("Downtime %s updated in row %d", d.ID, d.Row)
+	return nil
+}
+
+// downtimeValues формирует значения строки листа простоев
+func downtimeValues(d Downtime) []interface{} {
+	end := ""
+	if d.End != nil {
+		end = d.End.Format(dateTimeFormat)
+	}
+	return []interface{}{d.ID, d.Machine, d.Reason, d.Start.Format(dateTimeFormat), end, d.Notes, d.FullName}
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"fmt"
+	"log"
+
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/sergekovalev/siberia/internal/config"
+)
+
+// Machine представляет единицу оборудования из реестра
+// Лист оборудования: A - идентификатор, B - название, C - тип, D - участок (цех)
+type Machine struct {
+	ID       string `json:"id"`       // Идентификатор (инвентарный номер)
+	Name     string `json:"name"`     // Название, например "Токарный 16К20"
+	Type     string `json:"type"`     // Тип: токарный, фрезерный и т.п.
+	Workshop string `json:"workshop"` // Участок (цех)
+}
+
+// ReadMachines читает реестр оборудования
+func ReadMachines(srv *sheets.Service, cfg config.Config) ([]Machine, error) {
+	rows, err := readRows(srv, cfg.SpreadsheetID, sheetRange(cfg.MachinesSheet, "A2:D"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read machines: %v", err)
+	}
+
+	machines := make([]Machine, 0, len(rows))
+	for _, row := range rows {
+		if cell(row, 0) == "" {
+			continue // Пропускаем пустые строки
+		}
+		machines = append(machines, Machine{
+			ID:       cell(row, 0),
+			Name:     cell(row, 1),
+			Type:     cell(row, 2),
+			Workshop: cell(row, 3),
+		})
+	}
+	return machines, nil
+}
+
+// FindMachine ищет станок по идентификатору, возвращает nil, если станок не найден
+func FindMachine(srv *sheets.Service, cfg config.Config, id string) (*Machine, error) {
+	machines, err := ReadMachines(srv, cfg)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range machines {
+		if m.ID == id {
+			return &m, nil
+		}
+	}
+	return nil, nil
+}
+
+// AppendMachine добавляет станок в реестр оборудования
+func AppendMachine(srv *sheets.Service, cfg config.Config, m Machine) error {
+	row, err := appendRow(srv, cfg.SpreadsheetID, cfg.MachinesSheet, []interface{}{m.ID, m.Name, m.Type, m.Workshop})
+	if err != nil {
+		return err
+	}
+	log.Printf("Machine %s written to row %d", m.ID, row)
+	return nil
+}
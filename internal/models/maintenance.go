@@ -0,0 +1,110 @@
+package models
+
+import (
+	"log"
+	"time"
+
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/sergekovalev/siberia/internal/config"
+)
+
+// Приоритеты заявок на ремонт
+const (
+	PriorityLow      = "low"
+	PriorityNormal   = "normal"
+	PriorityHigh     = "high"
+	PriorityCritical = "critical"
+)
+
+// Статусы заявок на ремонт
+const (
+	TicketOpen       = "open"
+	TicketInProgress = "in_progress"
+	TicketClosed     = "closed"
+)
+
+// ValidPriority проверяет, что приоритет заявки известен
+func ValidPriority(p string) bool {
+	switch p {
+	case PriorityLow, PriorityNormal, PriorityHigh, PriorityCritical:
+		return true
+	}
+	return false
+}
+
+// ValidTicketStatus проверяет, что статус заявки известен
+func ValidTicketStatus(s string) bool {
+	switch s {
+	case TicketOpen, TicketInProgress, TicketClosed:
+		return true
+	}
+	return false
+}
+
+// MaintenanceTicket представляет заявку на ремонт оборудования
+// Заявки хранятся в каталоге данных приложения; при заданном листе копия заявки ведется в таблице:
+// A - идентификатор, B - станок, C - описание, D - приоритет, E - статус, F - исполнитель,
+// G - автор, H - создана, I - изменена, J - закрыта
+type MaintenanceTicket struct {
+	ID          string     `json:"id"`                  // Идентификатор заявки
+	Machine     string     `json:"machine"`             // Идентификатор станка
+	Description string     `json:"description"`         // Описание неисправности
+	Priority    string     `json:"priority"`            // Приоритет: low, normal, high, critical
+	Status      string     `json:"status"`              // Статус: open, in_progress, closed
+	Assignee    string     `json:"assignee"`            // Исполнитель (ремонтник)
+	Reporter    string     `json:"reporter"`            // Сотрудник, создавший заявку
+	CreatedAt   time.Time  `json:"createdAt"`           // Время создания
+	UpdatedAt   time.Time  `json:"updatedAt"`           // Время последнего изменения
+	ClosedAt    *time.Time `json:"closedAt,omitempty"`  // Время закрытия
+	MirrorRow   int        `json:"mirrorRow,omitempty"` // Номер строки на листе-копии
+}
+
+// AppendMaintenanceMirror добавляет копию заявки на лист таблицы и возвращает номер строки
+// Если лист-копия не настроен, возвращает 0
+func AppendMaintenanceMirror(srv *sheets.Service, cfg config.Config, t MaintenanceTicket) (int, error) {
+	if cfg.MaintenanceSheet == "" {
+		return 0, nil
+	}
+	row, err := appendRow(srv, cfg.SpreadsheetID, cfg.MaintenanceSheet, maintenanceValues(t))
+	if err != nil {
+		return 0, err
+	}
+	log.Printf("Maintenance ticket %s mirrored to row %d", t.ID, row)
+	return row, nil
+}
+
+// UpdateMaintenanceMirror перезаписывает копию заявки на листе таблицы
+// Если у заявки еще нет строки на листе, копия добавляется, и возвращается номер новой строки
+func UpdateMaintenanceMirror(srv *sheets.Service, cfg config.Config, t MaintenanceTicket) (int, error) {
+	if cfg.MaintenanceSheet == "" {
+		return 0, nil
+	}
+	if t.MirrorRow == 0 {
+		return AppendMaintenanceMirror(srv, cfg, t)
+	}
+	if err := updateRow(srv, cfg.SpreadsheetID, cfg.MaintenanceSheet, t.MirrorRow, maintenanceValues(t)); err != nil {
+		return t.MirrorRow, err
+	}
+	return t.MirrorRow, nil
+}
+
+// maintenanceValues формирует значения строки листа-копии заявок
+func maintenanceValues(t MaintenanceTicket) []interface{} {
+	closed := ""
+	if t.ClosedAt != nil {
+		closed = t.ClosedAt.Format(dateTimeFormat)
+	}
+	return []interface{}{
+		t.ID,
+		t.Machine,
+		t.Description,
+		t.Priority,
+		t.Status,
+		t.Assignee,
+		t.Reporter,
+		t.CreatedAt.Format(dateTimeFormat),
+		t.UpdatedAt.Format(dateTimeFormat),
+		closed,
+	}
+}
@@ -0,0 +1,224 @@
+package models
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/sergekovalev/siberia/internal/config"
+	"github.com/sergekovalev/siberia/internal/utils"
+)
+
+// materialMu сериализует изменение остатков материалов (чтение остатка и запись нового значения)
+var materialMu sync.Mutex
+
+// Material представляет материал (заготовку) на складе
+// Лист материалов: A - код, B - название, C - единица измерения, D - остаток, E - минимальный остаток,
+// F - цена за единицу (руб)
+type Material struct {
+	Row      int     `json:"row,omitempty"` // Номер строки на листе материалов
+	Code     string  `json:"code"`          // Код материала
+	Name     string  `json:"name"`          // Название, например "Пруток Д16Т ф30"
+	Unit     string  `json:"unit"`          // Единица измерения: кг, м, шт
+	Stock    float64 `json:"stock"`         // Остаток на складе (может быть отрицательным, если расход не оприходован)
+	MinStock float64 `json:"minStock"`      // Минимальный остаток, при котором выдается предупреждение
+	Price    float64 `json:"price"`         // Цена за единицу материала, руб
+}
+
+// Low проверяет, что остаток материала не превышает минимальный
+func (m Material) Low() bool {
+	return m.Stock <= m.MinStock
+}
+
+// BOMLine представляет строку спецификации: норму расхода материала на одну деталь
+// Лист спецификаций: A - деталь, B - код материала, C - расход на деталь (с учетом отходов)
+type BOMLine struct {
+	Part     string  `json:"part"`     // Деталь
+	Material string  `json:"material"` // Код материала
+	PerPiece float64 `json:"perPiece"` // Расход на одну деталь в единицах материала
+}
+
+// MaterialReceipt представляет поступление материала на склад
+// Лист поступлений: A - дата, B - код материала, C - количество, D - документ, E - сотрудник
+type MaterialReceipt struct {
+	Date     string  `json:"date"`     // Дата поступления (YYYY-MM-DD)
+	Material string  `json:"material"` // Код материала
+	Quantity float64 `json:"quantity"` // Количество в единицах материала
+	Document string  `json:"document"` // Номер накладной
+	FullName string  `json:"fullName"` // Сотрудник, принявший материал
+}
+
+// ReadMaterials читает справочник материалов с остатками
+func ReadMaterials(srv *sheets.Service, cfg config.Config) ([]Material, error) {
+	rows, err := readRows(srv, cfg.SpreadsheetID, sheetRange(cfg.MaterialsSheet, "A2:F"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read materials: %v", err)
+	}
+
+	materials := make([]Material, 0, len(rows))
+	for i, row := range rows {
+		if cell(row, 0) == "" {
+			continue // Пропускаем пустые строки
+		}
+
+		stock, errStock := parseOptionalNumber(cell(row, 3))
+		minStock, errMin := parseOptionalNumber(cell(row, 4))
+		price, errPrice := parseOptionalNumber(cell(row, 5))
+		if errStock != nil || errMin != nil || errPrice != nil {
+			log.Printf("Skipping material in row %d: invalid stock values", i+2)
+			continue
+		}
+
+		materials = append(materials, Material{
+			Row:      i + 2,
+			Code:     cell(row, 0),
+			Name:     cell(row, 1),
+			Unit:     cell(row, 2),
+			Stock:    stock,
+			MinStock: minStock,
+			Price:    price,
+		})
+	}
+	return materials, nil
+}
+
+// FindMaterial ищет материал по коду, возвращает nil, если материал не найден
+func FindMaterial(srv *sheets.Service, cfg config.Config, code string) (*Material, error) {
+	materials, err := ReadMaterials(srv, cfg)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range materials {
+		if m.Code == code {
+			return &m, nil
+		}
+	}
+	return nil, nil
+}
+
+// AppendMaterial добавляет материал в справочник
+func AppendMaterial(srv *sheets.Service, cfg config.Config, m Material) error {
+	row, err := appendRow(srv, cfg.SpreadsheetID, cfg.MaterialsSheet, materialValues(m))
+	if err != nil {
+		return err
+	}
+	log.Printf("Material %s written to row %d", m.Code, row)
+	return nil
+}
+
+// ReadBOM читает спецификации деталей
+func ReadBOM(srv *sheets.Service, cfg config.Config) ([]BOMLine, error) {
+	rows, err := readRows(srv, cfg.SpreadsheetID, sheetRange(cfg.BOMSheet, "A2:C"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read bill of materials: %v", err)
+	}
+
+	lines := make([]BOMLine, 0, len(rows))
+	for i, row := range rows {
+		if cell(row, 0) == "" || cell(row, 1) == "" {
+			continue // Пропускаем пустые строки
+		}
+
+		perPiece, err := utils.ParseNumber(cell(row, 2))
+		if err != nil {
+			log.Printf("Skipping bill of materials row %d: invalid consumption: %v", i+2, err)
+			continue
+		}
+		lines = append(lines, BOMLine{Part: cell(row, 0), Material: cell(row, 1), PerPiece: perPiece})
+	}
+	return lines, nil
+}
+
+// SaveBOMLine добавляет строку спецификации или обновляет норму расхода материала на деталь
+func SaveBOMLine(srv *sheets.Service, cfg config.Config, line BOMLine) error {
+	rows, err := readRows(srv, cfg.SpreadsheetID, sheetRange(cfg.BOMSheet, "A:B"))
+	if err != nil {
+		return fmt.Errorf("failed to read bill of materials: %v", err)
+	}
+
+	values := []interface{}{line.Part, line.Material, line.PerPiece}
+	for i, row := range rows {
+		if i > 0 && cell(row, 0) == line.Part && cell(row, 1) == line.Material {
+			return updateRow(srv, cfg.SpreadsheetID, cfg.BOMSheet, i+1, values)
+		}
+	}
+
+	// Материала в спецификации детали еще нет - добавляем новую строку
+	_, err = appendRow(srv, cfg.SpreadsheetID, cfg.BOMSheet, values)
+	return err
+}
+
+// ReceiveMaterial записывает поступление материала в журнал и увеличивает остаток на складе
+func ReceiveMaterial(srv *sheets.Service, cfg config.Config, receipt MaterialReceipt) (Material, error) {
+	materialMu.Lock()
+	defer materialMu.Unlock()
+
+	m, err := FindMaterial(srv, cfg, receipt.Material)
+	if err != nil {
+		return Material{}, err
+	}
+	if m == nil {
+		return Material{}, fmt.Errorf("material %s not found", receipt.Material)
+	}
+
+	if receipt.Date == "" {
+		receipt.Date = time.Now().Format("2006-01-02")
+	}
+	values := []interface{}{receipt.Date, receipt.Material, receipt.Quantity, receipt.Document, receipt.FullName}
+	if _, err := appendRow(srv, cfg.SpreadsheetID, cfg.MaterialReceiptsSheet, values); err != nil {
+		return *m, fmt.Errorf("failed to write material receipt: %v", err)
+	}
+
+	m.Stock = roundQuantity(m.Stock + receipt.Quantity)
+	if err := updateRow(srv, cfg.SpreadsheetID, cfg.MaterialsSheet, m.Row, materialValues(*m)); err != nil {
+		return *m, err
+	}
+	log.Printf("Material %s received: %v %s", m.Code, receipt.Quantity, m.Unit)
+	return *m, nil
+}
+
+// ConsumeMaterials списывает материалы по спецификации на parts деталей
+// и возвращает материалы с обновленными остатками
+func ConsumeMaterials(srv *sheets.Service, cfg config.Config, lines []BOMLine, parts int) ([]Material, error) {
+	materialMu.Lock()
+	defer materialMu.Unlock()
+
+	materials, err := ReadMaterials(srv, cfg)
+	if err != nil {
+		return nil, err
+	}
+	byCode := make(map[string]Material, len(materials))
+	for _, m := range materials {
+		byCode[m.Code] = m
+	}
+
+	used := make([]Material, 0, len(lines))
+	for _, line := range lines {
+		m, ok := byCode[line.Material]
+		if !ok {
+			log.Printf("Material %s from bill of materials of %s not found", line.Material, line.Part)
+			continue
+		}
+		m.Stock = roundQuantity(m.Stock - line.PerPiece*float64(parts))
+		if err := updateRow(srv, cfg.SpreadsheetID, cfg.MaterialsSheet, m.Row, materialValues(m)); err != nil {
+			return used, fmt.Errorf("failed to update material %s: %v", m.Code, err)
+		}
+		byCode[m.Code] = m // Один материал может встречаться в спецификации несколько раз
+		used = append(used, m)
+	}
+	return used, nil
+}
+
+// materialValues формирует значения строки листа материалов
+func materialValues(m Material) []interface{} {
+	return []interface{}{m.Code, m.Name, m.Unit, m.Stock, m.MinStock, m.Price}
+}
+
+// roundQuantity округляет количество материала до тысячных, чтобы в таблице не накапливалась погрешность
+func roundQuantity(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
@@ -0,0 +1,117 @@
+package models
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/sergekovalev/siberia/internal/config"
+	"github.com/sergekovalev/siberia/internal/utils"
+)
+
+// Operation представляет операцию из справочника с нормами времени и местом в маршруте детали
+// Лист справочника: A - деталь и операция, B - штучное время (мин), C - подготовительное время (мин),
+// D - деталь, E - номер операции в маршруте, F - стоимость труда на одну деталь (руб)
+type Operation struct {
+	Name         string  `json:"name"`         // Деталь и операция (как в форме учета производства)
+	CycleMinutes float64 `json:"cycleMinutes"` // Штучное время на одну деталь, мин
+	SetupMinutes float64 `json:"setupMinutes"` // Подготовительно-заключительное время на одну запись выпуска, мин
+	Part         string  `json:"part"`         // Деталь, к маршруту которой относится операция
+	Sequence     int     `json:"sequence"`     // Порядковый номер операции в маршруте (0 - вне маршрута)
+	LabourCost   float64 `json:"labourCost"`   // Стоимость труда на одну деталь, руб (0 - считается по штучному времени)
+}
+
+// ReadOperations читает справочник операций с листа нормативов
+func ReadOperations(srv *sheets.Service, cfg config.Config) ([]Operation, error) {
+	rows, err := readRows(srv, cfg.SpreadsheetID, sheetRange(cfg.OperationsSheet, "A2:F"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read operations: %v", err)
+	}
+
+	operations := make([]Operation, 0, len(rows))
+	for i, row := range rows {
+		name := cell(row, 0)
+		if name == "" {
+			continue // Пропускаем пустые строки
+		}
+
+		op := Operation{Name: name, Part: cell(row, 3)}
+		if op.CycleMinutes, err = parseOptionalNumber(cell(row, 1)); err != nil {
+			log.Printf("Skipping operation in row %d: invalid cycle time: %v", i+2, err)
+			continue
+		}
+		if op.SetupMinutes, err = parseOptionalNumber(cell(row, 2)); err != nil {
+			log.Printf("Skipping operation in row %d: invalid setup time: %v", i+2, err)
+			continue
+		}
+		sequence, err := parseOptionalNumber(cell(row, 4))
+		if err != nil {
+			log.Printf("Skipping operation in row %d: invalid sequence: %v", i+2, err)
+			continue
+		}
+		op.Sequence = int(sequence)
+		if op.LabourCost, err = parseOptionalNumber(cell(row, 5)); err != nil {
+			log.Printf("Skipping operation in row %d: invalid labour cost: %v", i+2, err)
+			continue
+		}
+		operations = append(operations, op)
+	}
+	return operations, nil
+}
+
+// OperationsByName строит индекс справочника операций по названию
+func OperationsByName(operations []Operation) map[string]Operation {
+	index := make(map[string]Operation, len(operations))
+	for _, op := range operations {
+		index[op.Name] = op
+	}
+	return index
+}
+
+// Routings строит маршруты деталей: деталь -> операции в порядке номеров
+// Операции без детали или номера в маршрут не входят
+func Routings(operations []Operation) map[string][]Operation {
+	routings := make(map[string][]Operation)
+	for _, op := range operations {
+		if op.Part == "" || op.Sequence <= 0 {
+			continue
+		}
+		routings[op.Part] = append(routings[op.Part], op)
+	}
+	for _, route := range routings {
+		sort.SliceStable(route, func(i, j int) bool { return route[i].Sequence < route[j].Sequence })
+	}
+	return routings
+}
+
+// SaveOperation добавляет операцию в справочник или перезаписывает строку существующей операции целиком
+// Частичное изменение объединяется с прежними значениями в обработчике до вызова
+func SaveOperation(srv *sheets.Service, cfg config.Config, op Operation) error {
+	// Ищем строку с таким же названием операции
+	rows, err := readRows(srv, cfg.SpreadsheetID, sheetRange(cfg.OperationsSheet, "A:A"))
+	if err != nil {
+		return fmt.Errorf("failed to read operations: %v", err)
+	}
+
+	values := []interface{}{op.Name, op.CycleMinutes, op.SetupMinutes, op.Part, op.Sequence, op.LabourCost}
+	for i, row := range rows {
+		if i > 0 && cell(row, 0) == op.Name {
+			return updateRow(srv, cfg.SpreadsheetID, cfg.OperationsSheet, i+1, values)
+		}
+	}
+
+	// Операция не найдена - добавляем новую строку
+	_, err = appendRow(srv, cfg.SpreadsheetID, cfg.OperationsSheet, values)
+	return err
+}
+
+// parseOptionalNumber разбирает число из ячейки, пустая ячейка считается нулем
+func parseOptionalNumber(s string) (float64, error) {
+	if strings.TrimSpace(s) == "" {
+		return 0, nil
+	}
+	return utils.ParseNumber(s)
+}
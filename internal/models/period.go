@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// ClosedPeriod представляет закрытый период: записи о выпуске и табель с датой внутри периода
+// нельзя добавлять и изменять. Периоды хранятся в каталоге данных приложения
+type ClosedPeriod struct {
+	ID       string    `json:"id"`             // Идентификатор периода
+	From     string    `json:"from"`           // Первый день периода (YYYY-MM-DD)
+	To       string    `json:"to"`             // Последний день периода (YYYY-MM-DD)
+	Note     string    `json:"note,omitempty"` // Комментарий, например "Зарплата за апрель рассчитана"
+	ClosedBy string    `json:"closedBy"`       // Пользователь, закрывший период
+	ClosedAt time.Time `json:"closedAt"`       // Время закрытия
+}
+
+// Contains проверяет, что дата (YYYY-MM-DD) входит в период
+// Даты в формате YYYY-MM-DD сравниваются как строки
+func (p ClosedPeriod) Contains(date string) bool {
+	return date >= p.From && date <= p.To
+}
+
+// Overlaps проверяет, что периоды пересекаются
+func (p ClosedPeriod) Overlaps(other ClosedPeriod) bool {
+	return p.From <= other.To && other.From <= p.To
+}
+
+// FindClosedPeriod возвращает закрытый период, в который входит дата, или nil
+func FindClosedPeriod(periods []ClosedPeriod, date string) *ClosedPeriod {
+	for _, p := range periods {
+		if p.Contains(date) {
+			return &p
+		}
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package models
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/sergekovalev/siberia/internal/config"
+	"github.com/sergekovalev/siberia/internal/utils"
+)
+
+// PlanTarget представляет плановое задание на день или неделю
+// Лист плана: A - дата (YYYY-MM-DD) или неделя (YYYY-Www), B - деталь и операция, C - количество, D - сотрудник
+type PlanTarget struct {
+	Row              int    `json:"row,omitempty"`      // Номер строки на листе плана
+	Period           string `json:"period"`             // Дата (YYYY-MM-DD) или неделя (YYYY-Www)
+	PartAndOperation string `json:"partAndOperation"`   // Деталь и операция
+	Quantity         int    `json:"quantity"`           // Плановое количество годных деталей
+	Employee         string `json:"employee,omitempty"` // Сотрудник (необязательно)
+}
+
+// Dates возвращает первый и последний день планового периода
+func (t PlanTarget) Dates() (from, to time.Time, err error) {
+	if strings.Contains(t.Period, "-W") {
+		monday, err := utils.ParseISOWeek(t.Period)
+		if err != nil {
+			return from, to, err
+		}
+		return monday, monday.AddDate(0, 0, 6), nil
+	}
+
+	date, err := utils.ParseDate(t.Period)
+	if err != nil {
+		return from, to, fmt.Errorf("invalid plan period %q, expected YYYY-MM-DD or YYYY-Www", t.Period)
+	}
+	return date, date, nil
+}
+
+// ReadPlan читает плановые задания с листа плана
+func ReadPlan(srv *sheets.Service, cfg config.Config) ([]PlanTarget, error) {
+	rows, err := readRows(srv, cfg.SpreadsheetID, sheetRange(cfg.PlanSheet, "A2:D"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plan: %v", err)
+	}
+
+	targets := make([]PlanTarget, 0, len(rows))
+	for i, row := range rows {
+		if cell(row, 0) == "" || cell(row, 1) == "" {
+			continue // Пропускаем пустые строки
+		}
+
+		target := PlanTarget{
+			Row:              i + 2,
+			Period:           cell(row, 0),
+			PartAndOperation: cell(row, 1),
+			Employee:         cell(row, 3),
+		}
+
+		// Приводим дату к формату YYYY-MM-DD (таблица может вернуть ее в формате отображения)
+		from, _, err := target.Dates()
+		if err != nil {
+			log.Printf("Skipping plan row %d: %v", target.Row, err)
+			continue
+		}
+		if !strings.Contains(target.Period, "-W") {
+			target.Period = from.Format("2006-01-02")
+		}
+
+		quantity, err := utils.ParseNumber(cell(row, 2))
+		if err != nil {
+			log.Printf("Skipping plan row %d: invalid quantity: %v", target.Row, err)
+			continue
+		}
+		target.Quantity = int(quantity)
+
+		targets = append(targets, target)
+	}
+	return targets, nil
+}
+
+// AppendPlanTarget добавляет плановое задание на лист плана
+func AppendPlanTarget(srv *sheets.Service, cfg config.Config, target PlanTarget) error {
+	values := []interface{}{
+		target.Period,
+		target.PartAndOperation,
+		target.Quantity,
+		target.Employee,
+	}
+	row, err := appendRow(srv, cfg.SpreadsheetID, cfg.PlanSheet, values)
+	if err != nil {
+		return err
+	}
+	log.Printf("Plan target written to row %d", row)
+	return nil
+}
@@ -10,6 +10,7 @@ import (
 	"google.golang.org/api/sheets/v4"
 
 	"github.com/sergekovalev/siberia/internal/config"
+	"github.com/sergekovalev/siberia/internal/utils"
 )
 
 // ProductionData представляет структуру данных о производстве
@@ -21,49 +22,105 @@ type ProductionData struct {
 	Defective        string `json:"defective"`        // Количество дефектных деталей
 	GoodParts        string `json:"goodParts"`        // Количество годных деталей
 	Notes            string `json:"notes"`            // Примечания
+	Lot              string `json:"lot"`              // Номер партии (заказа), необязательно
+	Machine          string `json:"machine"`          // Идентификатор станка, необязательно
+	Shift            string `json:"shift"`            // Название смены, необязательно
+	DefectReason     string `json:"defectReason"`     // Код причины брака, необязательно
 }
 
-// AppendProductionData добавляет данные о производстве в Google Sheets
-func AppendProductionData(srv *sheets.Service, cfg config.Config, data ProductionData) error {
-	// Находим последнюю непустую строку в таблице
-	lastRow, err := findLastNonEmptyRow(srv, cfg.SpreadsheetID, cfg.ProductionSheet)
-	if err != nil {
-		return fmt.Errorf("failed to find last row: %v", err) // Возвращаем ошибку, если не удалось найти строку
-	}
+// ProductionRecord представляет запись о производстве, прочитанную из Google Sheets
+type ProductionRecord struct {
+	Row              int       `json:"row"`              // Номер строки на листе выпуска
+	Date             time.Time `json:"date"`             // Дата производства
+	FullName         string    `json:"fullName"`         // Полное имя сотрудника
+	PartAndOperation string    `json:"partAndOperation"` // Деталь и операция
+	TotalParts       int       `json:"totalParts"`       // Общее количество деталей
+	Defective        int       `json:"defective"`        // Количество дефектных деталей
+	GoodParts        int       `json:"goodParts"`        // Количество годных деталей
+	Notes            string    `json:"notes"`            // Примечания
+	Lot              string    `json:"lot"`              // Номер партии (заказа)
+	Machine          string    `json:"machine"`          // Идентификатор станка
+	Shift            string    `json:"shift"`            // Название смены
+	DefectReason     string    `json:"defectReason"`     // Код причины брака
+}
 
-	// Определяем строку, в которую будут добавлены данные
-	targetRow := lastRow + 1
-	values := [][]interface{}{
-		{
-			data.Date,
-			data.FullName,
-			data.PartAndOperation,
-			data.TotalParts,
-			data.Defective,
-			data.GoodParts,
-			data.Notes,
-		},
+// AppendProductionData добавляет данные о производстве в Google Sheets и возвращает номер строки записи
+// Номер строки используется как ссылка на запись (например, в записях о доработке)
+func AppendProductionData(srv *sheets.Service, cfg config.Config, data ProductionData) (int, error) {
+	values := []interface{}{
+		data.Date,
+		data.FullName,
+		data.PartAndOperation,
+		data.TotalParts,
+		data.Defective,
+		data.GoodParts,
+		data.Notes,
+		data.Lot,
+		data.Machine,
+		data.Shift,
+		data.DefectReason,
 	}
 
-	// Устанавливаем контекст с таймаутом для выполнения запроса
-	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
-	defer cancel()
-
-	// Формируем диапазон для обновления данных
-	rangeData := fmt.Sprintf("%s!A%d:G%d", cfg.ProductionSheet, targetRow, targetRow)
-	_, err = srv.Spreadsheets.Values.Update(
-		cfg.SpreadsheetID,
-		rangeData,
-		&sheets.ValueRange{Values: values},
-	).ValueInputOption("USER_ENTERED").Context(ctx).Do()
-
+	// Записываем данные в первую свободную строку листа выпуска
+	targetRow, err := appendRow(srv, cfg.SpreadsheetID, cfg.ProductionSheet, values)
 	if err != nil {
-		return fmt.Errorf("failed to update sheet: %v", err) // Возвращаем ошибку, если не удалось обновить таблицу
+		return 0, err // Возвращаем ошибку, если не удалось записать строку
 	}
 
 	// Логируем успешное добавление данных
 	log.Printf("Production data written to row %d", targetRow)
-	return nil
+	return targetRow, nil
+}
+
+// ReadProductionData читает все записи о производстве с листа выпуска
+// Строки без корректной даты (заголовок, пустые строки) пропускаются
+func ReadProductionData(srv *sheets.Service, cfg config.Config) ([]ProductionRecord, error) {
+	rows, err := readRows(srv, cfg.SpreadsheetID, sheetRange(cfg.ProductionSheet, "A2:K"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read production data: %v", err)
+	}
+
+	records := make([]ProductionRecord, 0, len(rows))
+	for i, row := range rows {
+		rowNumber := i + 2 // Данные начинаются со второй строки
+		if cell(row, 0) == "" {
+			continue
+		}
+
+		date, err := utils.ParseDate(cell(row, 0))
+		if err != nil {
+			log.Printf("Skipping production row %d: %v", rowNumber, err)
+			continue
+		}
+
+		total, errTotal := parseOptionalNumber(cell(row, 3))
+		defective, errDefective := parseOptionalNumber(cell(row, 4))
+		good, errGood := parseOptionalNumber(cell(row, 5))
+		if errTotal != nil || errDefective != nil || errGood != nil {
+			log.Printf("Skipping production row %d: invalid quantities", rowNumber)
+			continue
+		}
+		// Если количество годных не заполнено, вычисляем его из общего количества и брака
+		if cell(row, 5) == "" {
+			good = total - defective
+		}
+
+		records = append(records, ProductionRecord{
+			Row:              rowNumber,
+			Date:             date,
+			FullName:         cell(row, 1),
+			PartAndOperation: cell(row, 2),
+			TotalParts:       int(total),
+			Defective:        int(defective),
+			GoodParts:        int(good),
+			Notes:            cell(row, 6),
+			Lot:              cell(row, 7),
+			Machine:          cell(row, 8),
+			Shift:            cell(row, 9),
+			DefectReason:     cell(row, 10),
+		})
+	}
+	return records, nil
 }
 
 // findLastNonEmptyRow находит последнюю непустую строку в указанном листе Google Sheets
@@ -75,7 +132,7 @@ func findLastNonEmptyRow(srv *sheets.Service, spreadsheetID, sheetName string) (
 	// Получаем данные из первого столбца (A:A) указанного листа
 	resp, err := srv.Spreadsheets.Values.Get(
 		spreadsheetID,
-		fmt.Sprintf("%s!A:A", sheetName),
+		sheetRange(sheetName, "A:A"),
 	).Context(ctx).Do()
 
 	if err != nil {
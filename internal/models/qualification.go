@@ -0,0 +1,101 @@
+package models
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/sergekovalev/siberia/internal/config"
+)
+
+// Qualification представляет допуск сотрудника к операции (строку матрицы квалификации)
+// Лист квалификации: A - сотрудник, B - деталь и операция, C - уровень, D - действует до (пусто - бессрочно)
+type Qualification struct {
+	Row       int    `json:"row,omitempty"` // Номер строки на листе квалификации
+	Employee  string `json:"employee"`      // ФИО сотрудника
+	Operation string `json:"operation"`     // Деталь и операция
+	Level     int    `json:"level"`         // Уровень квалификации
+	Expires   string `json:"expires"`       // Срок действия допуска (YYYY-MM-DD), пусто - бессрочно
+}
+
+// ValidOn проверяет, что допуск действует в указанный день
+func (q Qualification) ValidOn(date time.Time) bool {
+	if q.Expires == "" {
+		return true
+	}
+	expires, err := time.Parse("2006-01-02", q.Expires)
+	if err != nil {
+		return false // Нераспознанный срок считаем истекшим, чтобы ошибку в таблице заметили
+	}
+	return !date.After(expires)
+}
+
+// ReadQualifications читает матрицу квалификации; если листа матрицы нет, возвращает пустой список
+func ReadQualifications(srv *sheets.Service, cfg config.Config) ([]Qualification, error) {
+	rows, err := readRows(srv, cfg.SpreadsheetID, sheetRange(cfg.QualificationsSheet, "A2:D"))
+	if missingSheet(err) {
+		return []Qualification{}, nil // Лист еще не заведен: матрица пуста
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read qualifications: %v", err)
+	}
+
+	qualifications := make([]Qualification, 0, len(rows))
+	for i, row := range rows {
+		if cell(row, 0) == "" || cell(row, 1) == "" {
+			continue // Пропускаем пустые строки
+		}
+
+		level, err := parseOptionalNumber(cell(row, 2))
+		if err != nil {
+			log.Printf("Skipping qualification in row %d: invalid level: %v", i+2, err)
+			continue
+		}
+
+		qualifications = append(qualifications, Qualification{
+			Row:       i + 2,
+			Employee:  cell(row, 0),
+			Operation: cell(row, 1),
+			Level:     int(level),
+			Expires:   normalizeDate(cell(row, 3)),
+		})
+	}
+	return qualifications, nil
+}
+
+// FindQualification ищет допуск сотрудника к операции, возвращает nil, если допуска нет
+func FindQualification(qualifications []Qualification, employee, operation string) *Qualification {
+	for _, q := range qualifications {
+		if q.Employee == employee && q.Operation == operation {
+			return &q
+		}
+	}
+	return nil
+}
+
+// SaveQualification добавляет допуск в матрицу или обновляет уровень и срок существующего допуска
+func SaveQualification(srv *sheets.Service, cfg config.Config, q Qualification) error {
+	qualifications, err := ReadQualifications(srv, cfg)
+	if err != nil {
+		return err
+	}
+
+	values := []interface{}{q.Employee, q.Operation, q.Level, q.Expires}
+	if existing := FindQualification(qualifications, q.Employee, q.Operation); existing != nil {
+		if err := updateRow(srv, cfg.SpreadsheetID, cfg.QualificationsSheet, existing.Row, values); err != nil {
+			return err
+		}
+		log.Printf("Qualification of %s for %s updated in row %d", q.Employee, q.Operation, existing.Row)
+		return nil
+	}
+
+	// Допуска еще нет - добавляем новую строку
+	row, err := appendRow(srv, cfg.SpreadsheetID, cfg.QualificationsSheet, values)
+	if err != nil {
+		return err
+	}
+	log.Printf("Qualification of %s for %s written to row %d", q.Employee, q.Operation, row)
+	return nil
+}
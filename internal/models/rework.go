@@ -0,0 +1,102 @@
+package models
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/sergekovalev/siberia/internal/config"
+	"github.com/sergekovalev/siberia/internal/utils"
+)
+
+// ReworkData представляет запись о доработке бракованных деталей
+// Запись ссылается на исходную запись о производстве (номер строки листа выпуска) или на партию.
+// Лист доработки: A - дата, B - сотрудник, C - строка выпуска, D - партия, E - операция, на которой
+// допущен брак, F - доработано, G - исправлено, H - окончательно списано, I - примечания
+type ReworkData struct {
+	Row              int    `json:"row,omitempty"`           // Номер строки на листе доработки
+	Date             string `json:"date"`                    // Дата доработки (YYYY-MM-DD)
+	FullName         string `json:"fullName"`                // Сотрудник, выполнявший доработку
+	ProductionRow    int    `json:"productionRow,omitempty"` // Строка исходной записи на листе выпуска
+	Lot              string `json:"lot,omitempty"`           // Номер партии
+	PartAndOperation string `json:"partAndOperation"`        // Операция, на которой был допущен брак
+	Quantity         int    `json:"quantity"`                // Количество деталей, отданных в доработку
+	Fixed            int    `json:"fixed"`                   // Исправлено (стали годными)
+	Scrapped         int    `json:"scrapped"`                // Окончательно списано
+	Notes            string `json:"notes"`                   // Примечания
+}
+
+// AppendReworkData добавляет запись о доработке на лист доработки
+func AppendReworkData(srv *sheets.Service, cfg config.Config, data ReworkData) (int, error) {
+	productionRow := ""
+	if data.ProductionRow > 0 {
+		productionRow = strconv.Itoa(data.ProductionRow)
+	}
+
+	values := []interface{}{
+		data.Date,
+		data.FullName,
+		productionRow,
+		data.Lot,
+		data.PartAndOperation,
+		data.Quantity,
+		data.Fixed,
+		data.Scrapped,
+		data.Notes,
+	}
+	row, err := appendRow(srv, cfg.SpreadsheetID, cfg.ReworkSheet, values)
+	if err != nil {
+		return 0, err
+	}
+	log.Printf("Rework data written to row %d", row)
+	return row, nil
+}
+
+// ReadReworkData читает записи о доработке
+func ReadReworkData(srv *sheets.Service, cfg config.Config) ([]ReworkData, error) {
+	rows, err := readRows(srv, cfg.SpreadsheetID, sheetRange(cfg.ReworkSheet, "A2:I"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rework data: %v", err)
+	}
+
+	records := make([]ReworkData, 0, len(rows))
+	for i, row := range rows {
+		if cell(row, 0) == "" {
+			continue // Пропускаем пустые строки
+		}
+
+		date, err := utils.ParseDate(cell(row, 0))
+		if err != nil {
+			log.Printf("Skipping rework row %d: %v", i+2, err)
+			continue
+		}
+
+		var numbers [4]float64
+		valid := true
+		for j, col := range []int{2, 5, 6, 7} {
+			if numbers[j], err = parseOptionalNumber(cell(row, col)); err != nil {
+				valid = false
+			}
+		}
+		if !valid {
+			log.Printf("Skipping rework row %d: invalid quantities", i+2)
+			continue
+		}
+
+		records = append(records, ReworkData{
+			Row:              i + 2,
+			Date:             date.Format("2006-01-02"),
+			FullName:         cell(row, 1),
+			ProductionRow:    int(numbers[0]),
+			Lot:              cell(row, 3),
+			PartAndOperation: cell(row, 4),
+			Quantity:         int(numbers[1]),
+			Fixed:            int(numbers[2]),
+			Scrapped:         int(numbers[3]),
+			Notes:            cell(row, 8),
+		})
+	}
+	return records, nil
+}
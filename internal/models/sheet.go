@@ -0,0 +1,116 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/sergekovalev/siberia/internal/utils"
+)
+
+// sheetMu сериализует добавление строк: поиск последней строки и запись должны выполняться атомарно,
+// иначе два параллельных запроса запишут данные в одну и ту же строку
+var sheetMu sync.Mutex
+
+// sheetRange формирует адрес диапазона с названием листа в кавычках (названия могут содержать пробелы)
+func sheetRange(sheetName, rangeA1 string) string {
+	return fmt.Sprintf("'%s'!%s", strings.ReplaceAll(sheetName, "'", "''"), rangeA1)
+}
+
+// missingSheet проверяет, что чтение не удалось из-за отсутствия листа в таблице
+// Google Sheets отвечает на диапазон несуществующего листа ошибкой 400 "Unable to parse range"
+func missingSheet(err error) bool {
+	var apiErr *googleapi.Error
+	return errors.As(err, &apiErr) && apiErr.Code == http.StatusBadRequest && strings.Contains(apiErr.Message, "Unable to parse range")
+}
+
+// readRows читает диапазон листа Google Sheets и возвращает значения ячеек в виде строк
+func readRows(srv *sheets.Service, spreadsheetID, rangeA1 string) ([][]string, error) {
+	// Устанавливаем контекст с таймаутом для выполнения запроса
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	resp, err := srv.Spreadsheets.Values.Get(spreadsheetID, rangeA1).Context(ctx).Do()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sheet data: %w", err) // Ошибка чтения диапазона
+	}
+
+	// Приводим все значения к строкам, чтобы вызывающий код не зависел от типов ячеек
+	rows := make([][]string, len(resp.Values))
+	for i, row := range resp.Values {
+		rows[i] = make([]string, len(row))
+		for j, value := range row {
+			rows[i][j] = strings.TrimSpace(fmt.Sprintf("%v", value))
+		}
+	}
+	return rows, nil
+}
+
+// cell возвращает значение столбца с индексом i или пустую строку, если строка короче
+// Google Sheets не возвращает пустые ячейки в конце строки
+func cell(row []string, i int) string {
+	if i < len(row) {
+		return row[i]
+	}
+	return ""
+}
+
+// appendRow записывает значения в первую свободную строку листа и возвращает номер этой строки
+func appendRow(srv *sheets.Service, spreadsheetID, sheetName string, values []interface{}) (int, error) {
+	sheetMu.Lock()
+	defer sheetMu.Unlock()
+
+	// Находим последнюю непустую строку в листе
+	lastRow, err := findLastNonEmptyRow(srv, spreadsheetID, sheetName)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find last row: %v", err)
+	}
+
+	targetRow := lastRow + 1
+	if err := updateRow(srv, spreadsheetID, sheetName, targetRow, values); err != nil {
+		return 0, err
+	}
+	return targetRow, nil
+}
+
+// cellValues готовит значения строки к записи в режиме USER_ENTERED: текст, который таблица
+// приняла бы за формулу (например, примечание "=IMPORTXML(...)"), записывается как текст.
+// Числа и числовые строки записываются без изменений и распознаются таблицей как числа
+func cellValues(values []interface{}) []interface{} {
+	safe := make([]interface{}, len(values))
+	for i, v := range values {
+		if s, ok := v.(string); ok {
+			v = utils.SafeCellText(s)
+		}
+		safe[i] = v
+	}
+	return safe
+}
+
+// updateRow перезаписывает строку листа с номером row, начиная со столбца A
+// Все записи в таблицу проходят через cellValues, поэтому значения из запросов не становятся формулами
+func updateRow(srv *sheets.Service, spreadsheetID, sheetName string, row int, values []interface{}) error {
+	// Устанавливаем контекст с таймаутом для выполнения запроса
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	// Формируем диапазон по количеству записываемых значений
+	rangeData := sheetRange(sheetName, fmt.Sprintf("A%d:%s%d", row, utils.ColumnToLetter(len(values)), row))
+	_, err := srv.Spreadsheets.Values.Update(
+		spreadsheetID,
+		rangeData,
+		&sheets.ValueRange{Values: [][]interface{}{cellValues(values)}},
+	).ValueInputOption("USER_ENTERED").Context(ctx).Do()
+
+	if err != nil {
+		return fmt.Errorf("failed to update sheet: %v", err) // Ошибка записи строки
+	}
+	return nil
+}
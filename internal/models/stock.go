@@ -0,0 +1,158 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/sergekovalev/siberia/internal/config"
+	"github.com/sergekovalev/siberia/internal/utils"
+)
+
+// Типы движений готовой продукции
+const (
+	MovementProduction = "production" // Поступление годных деталей с последней операции маршрута
+	MovementShipment   = "shipment"   // Отгрузка заказчику
+)
+
+// ErrInsufficientStock возвращается при попытке отгрузить больше деталей, чем есть на складе
+var ErrInsufficientStock = errors.New("insufficient stock")
+
+// stockMu сериализует движения готовой продукции: проверка остатка и запись отгрузки должны быть атомарными
+var stockMu sync.Mutex
+
+// StockMovement представляет движение готовой продукции на складе
+// Лист движения: A - дата, B - деталь, C - тип движения, D - количество (отгрузка - со знаком минус),
+// E - документ (номер накладной или строка выпуска), F - заказчик, G - сотрудник
+type StockMovement struct {
+	Row      int       `json:"row,omitempty"` // Номер строки на листе движения
+	Date     time.Time `json:"date"`          // Дата движения
+	Part     string    `json:"part"`          // Деталь
+	Type     string    `json:"type"`          // Тип движения: production, shipment
+	Quantity int       `json:"quantity"`      // Количество: приход - положительное, расход - отрицательное
+	Document string    `json:"document"`      // Номер документа
+	Customer string    `json:"customer"`      // Заказчик (для отгрузок)
+	FullName string    `json:"fullName"`      // Сотрудник
+}
+
+// StockBalance содержит остаток готовой продукции по детали
+type StockBalance struct {
+	Part     string `json:"part"`     // Деталь
+	Quantity int    `json:"quantity"` // Остаток на складе
+	Received int    `json:"received"` // Поступило с производства
+	Shipped  int    `json:"shipped"`  // Отгружено
+}
+
+// ReadStockMovements читает журнал движения готовой продукции
+func ReadStockMovements(srv *sheets.Service, cfg config.Config) ([]StockMovement, error) {
+	rows, err := readRows(srv, cfg.SpreadsheetID, sheetRange(cfg.StockSheet, "A2:G"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stock movements: %v", err)
+	}
+
+	movements := make([]StockMovement, 0, len(rows))
+	for i, row := range rows {
+		if cell(row, 0) == "" || cell(row, 1) == "" {
+			continue // Пропускаем пустые строки
+		}
+
+		date, err := utils.ParseDate(cell(row, 0))
+		if err != nil {
+			log.Printf("Skipping stock movement row %d: %v", i+2, err)
+			continue
+		}
+		quantity, err := utils.ParseNumber(cell(row, 3))
+		if err != nil {
+			log.Printf("Skipping stock movement row %d: invalid quantity: %v", i+2, err)
+			continue
+		}
+
+		movements = append(movements, StockMovement{
+			Row:      i + 2,
+			Date:     date,
+			Part:     cell(row, 1),
+			Type:     cell(row, 2),
+			Quantity: int(quantity),
+			Document: cell(row, 4),
+			Customer: cell(row, 5),
+			FullName: cell(row, 6),
+		})
+	}
+	return movements, nil
+}
+
+// StockBalances рассчитывает остатки готовой продукции по журналу движения
+func StockBalances(movements []StockMovement) []StockBalance {
+	index := make(map[string]*StockBalance)
+	for _, m := range movements {
+		b := index[m.Part]
+		if b == nil {
+			b = &StockBalance{Part: m.Part}
+			index[m.Part] = b
+		}
+		b.Quantity += m.Quantity
+		switch m.Type {
+		case MovementProduction:
+			b.Received += m.Quantity
+		case MovementShipment:
+			b.Shipped -= m.Quantity
+		}
+	}
+
+	balances := make([]StockBalance, 0, len(index))
+	for _, b := range index {
+		balances = append(balances, *b)
+	}
+	sort.Slice(balances, func(i, j int) bool { return balances[i].Part < balances[j].Part })
+	return balances
+}
+
+// ReceiveFinishedGoods записывает поступление годных деталей на склад готовой продукции
+func ReceiveFinishedGoods(srv *sheets.Service, cfg config.Config, m StockMovement) error {
+	stockMu.Lock()
+	defer stockMu.Unlock()
+
+	m.Type = MovementProduction
+	return appendStockMovement(srv, cfg, m)
+}
+
+// ShipFinishedGoods записывает отгрузку, если на складе достаточно деталей
+// Возвращает ErrInsufficientStock, если остаток меньше отгружаемого количества
+func ShipFinishedGoods(srv *sheets.Service, cfg config.Config, m StockMovement) error {
+	stockMu.Lock()
+	defer stockMu.Unlock()
+
+	movements, err := ReadStockMovements(srv, cfg)
+	if err != nil {
+		return err
+	}
+	available := 0
+	for _, prev := range movements {
+		if prev.Part == m.Part {
+			available += prev.Quantity
+		}
+	}
+	if available < m.Quantity {
+		return fmt.Errorf("%w: %d available", ErrInsufficientStock, available)
+	}
+
+	m.Type = MovementShipment
+	m.Quantity = -m.Quantity
+	return appendStockMovement(srv, cfg, m)
+}
+
+// appendStockMovement добавляет строку в журнал движения готовой продукции
+func appendStockMovement(srv *sheets.Service, cfg config.Config, m StockMovement) error {
+	values := []interface{}{m.Date.Format("2006-01-02"), m.Part, m.Type, m.Quantity, m.Document, m.Customer, m.FullName}
+	row, err := appendRow(srv, cfg.SpreadsheetID, cfg.StockSheet, values)
+	if err != nil {
+		return fmt.Errorf("failed to write stock movement: %v", err)
+	}
+	log.Printf("Stock movement %s of %d x %s written to row %d", m.Type, m.Quantity, m.Part, row)
+	return nil
+}
@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// Виды записей, поступающих на утверждение
+const (
+	SubmissionProduction = "production" // Запись о выпуске
+	SubmissionTimesheet  = "timesheet"  // Часы в табель
+)
+
+// Статусы записей на утверждении
+const (
+	SubmissionPending  = "pending"  // Ожидает решения мастера
+	SubmissionApproved = "approved" // Утверждена и записана в таблицу
+	SubmissionRejected = "rejected" // Отклонена с указанием причины
+)
+
+// ValidSubmissionStatus проверяет, что статус записи на утверждении известен
+func ValidSubmissionStatus(s string) bool {
+	switch s {
+	case SubmissionPending, SubmissionApproved, SubmissionRejected:
+		return true
+	}
+	return false
+}
+
+// Submission представляет запись оператора, ожидающую утверждения мастером
+// Записи хранятся в каталоге данных приложения; в таблицу попадают только утвержденные записи
+type Submission struct {
+	ID          string          `json:"id"`                   // Идентификатор записи
+	Kind        string          `json:"kind"`                 // Вид: production или timesheet
+	Status      string          `json:"status"`               // Статус: pending, approved, rejected
+	Production  *ProductionData `json:"production,omitempty"` // Данные о выпуске
+	Timesheet   *TimesheetData  `json:"timesheet,omitempty"`  // Данные табеля
+	Warnings    []string        `json:"warnings,omitempty"`   // Предупреждения, выданные при вводе
+	SubmittedBy string          `json:"submittedBy"`          // Идентификатор пользователя, внесшего запись
+	FullName    string          `json:"fullName"`             // Сотрудник, от имени которого внесена запись
+	SubmittedAt time.Time       `json:"submittedAt"`          // Время ввода
+	ReviewedBy  string          `json:"reviewedBy,omitempty"` // Мастер, принявший решение
+	ReviewedAt  *time.Time      `json:"reviewedAt,omitempty"` // Время решения
+	Corrected   bool            `json:"corrected,omitempty"`  // Мастер исправил данные перед утверждением
+	Reason      string          `json:"reason,omitempty"`     // Причина отклонения или исправления
+	Row         int             `json:"row,omitempty"`        // Номер строки записи о выпуске после утверждения
+}
@@ -2,12 +2,14 @@ package models
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/sergekovalev/siberia/internal/config"
 	"github.com/sergekovalev/siberia/internal/utils"
 	"google.golang.org/api/sheets/v4"
 )
@@ -40,7 +42,7 @@ func AppendTimesheetData(srv *sheets.Service, spreadsheetID string, data Timeshe
 		spreadsheetID,
 		cell,
 		&sheets.ValueRange{
-			Values: [][]interface{}{{data.Hours}}, // Записываем количество часов
+			Values: [][]interface{}{cellValues([]interface{}{data.Hours})}, // Записываем количество часов
 		},
 	).ValueInputOption("USER_ENTERED").Context(ctx).Do()
 
@@ -128,3 +130,71 @@ func findTimesheetCell(srv *sheets.Service, spreadsheetID string, data Timesheet
 	colLetter := utils.ColumnToLetter(targetCol)
 	return colLetter, targetRow, targetCol, nil
 }
+
+// ErrTimesheetNotFound возвращается, если в таблице нет листа табеля за запрошенный месяц
+var ErrTimesheetNotFound = errors.New("timesheet for the month not found")
+
+// TimesheetSheetFor возвращает название листа табеля за месяц month
+// Текущий месяц (по дате now) ведется на листе timesheetSheet, а табели прошедших месяцев хранятся
+// в копиях этого листа с месяцем в названии, например "Табель 2026-09"
+func TimesheetSheetFor(cfg config.Config, month, now time.Time) string {
+	if month.Year() == now.Year() && month.Month() == now.Month() {
+		return cfg.TimesheetSheet
+	}
+	return cfg.TimesheetSheet + " " + month.Format("2006-01")
+}
+
+// ReadTimesheetHours читает отработанные часы из табеля за месяц month (см. TimesheetSheetFor)
+// Табель ведется за один месяц и содержит только номера дней, поэтому даты строятся от месяца month.
+// Если листа за этот месяц нет, возвращает ErrTimesheetNotFound
+// Результат: сотрудник -> дата (YYYY-MM-DD) -> количество часов
+func ReadTimesheetHours(srv *sheets.Service, cfg config.Config, month time.Time) (map[string]map[string]float64, error) {
+	// Строка 3 содержит номера дней, строки 4-12 - имена сотрудников и часы (как в findTimesheetCell)
+	rows, err := readRows(srv, cfg.SpreadsheetID, sheetRange(TimesheetSheetFor(cfg, month, time.Now()), "B3:AG12"))
+	if missingSheet(err) {
+		return nil, ErrTimesheetNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read timesheet: %v", err)
+	}
+	if len(rows) == 0 {
+		return map[string]map[string]float64{}, nil
+	}
+
+	// Сопоставляем индекс столбца с датой по номерам дней из строки заголовка
+	dates := make(map[int]string)
+	for i, value := range rows[0] {
+		day, err := strconv.Atoi(value)
+		if i == 0 || err != nil {
+			continue // Первый столбец (B) содержит подпись, а не номер дня
+		}
+		date := time.Date(month.Year(), month.Month(), day, 0, 0, 0, 0, time.UTC)
+		if date.Month() != month.Month() {
+			continue // Пропускаем дни, которых нет в этом месяце
+		}
+		dates[i] = date.Format("2006-01-02")
+	}
+
+	hours := make(map[string]map[string]float64)
+	for _, row := range rows[1:] {
+		name := cell(row, 0)
+		if name == "" {
+			continue
+		}
+		for i, date := range dates {
+			value := cell(row, i)
+			if value == "" {
+				continue
+			}
+			h, err := utils.ParseNumber(value)
+			if err != nil {
+				continue // В табеле встречаются буквенные отметки (например, "В", "Б")
+			}
+			if hours[name] == nil {
+				hours[name] = make(map[string]float64)
+			}
+			hours[name][date] += h
+		}
+	}
+	return hours, nil
+}
@@ -0,0 +1,196 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/sergekovalev/siberia/internal/config"
+	"github.com/sergekovalev/siberia/internal/utils"
+)
+
+// toolMu сериализует изменения стойкости инструмента (списание и замену): чтение остатка и запись нового значения
+var toolMu sync.Mutex
+
+// ErrToolNotFound возвращается при замене инструмента, которого нет в реестре
+var ErrToolNotFound = errors.New("tool not found")
+
+// Tool представляет режущий инструмент (пластину, резец), установленный на станок
+// Лист инструмента: A - идентификатор, B - название, C - станок, D - операция (пусто - все операции
+// станка), E - стойкость в деталях, F - остаток стойкости, G - дата и время установки
+type Tool struct {
+	Row          int       `json:"row,omitempty"` // Номер строки на листе инструмента
+	ID           string    `json:"id"`            // Идентификатор инструмента
+	Name         string    `json:"name"`          // Название, например "Пластина CNMG 120408"
+	Machine      string    `json:"machine"`       // Станок
+	Operation    string    `json:"operation"`     // Деталь и операция (пусто - любая операция станка)
+	ExpectedLife int       `json:"expectedLife"`  // Ожидаемая стойкость, деталей
+	Remaining    int       `json:"remaining"`     // Остаток стойкости, деталей (может быть отрицательным)
+	Installed    time.Time `json:"installed"`     // Дата и время установки
+}
+
+// NearEndOfLife проверяет, что остаток стойкости не превышает warnPercent процентов от ресурса
+func (t Tool) NearEndOfLife(warnPercent int) bool {
+	return t.Remaining*100 <= t.ExpectedLife*warnPercent
+}
+
+// Applies проверяет, что инструмент используется на станке machine при выполнении операции operation
+func (t Tool) Applies(machine, operation string) bool {
+	return t.Machine == machine && (t.Operation == "" || t.Operation == operation)
+}
+
+// ToolChange представляет запись журнала замен инструмента
+// Лист замен: A - дата и время, B - инструмент, C - станок, D - причина, E - остаток на момент замены, F - сотрудник
+type ToolChange struct {
+	Time      time.Time `json:"time"`      // Дата и время замены
+	ToolID    string    `json:"toolId"`    // Идентификатор инструмента
+	Machine   string    `json:"machine"`   // Станок
+	Reason    string    `json:"reason"`    // Причина замены: износ, поломка, плановая замена
+	Remaining int       `json:"remaining"` // Остаток стойкости на момент замены
+	FullName  string    `json:"fullName"`  // Сотрудник, выполнивший замену
+}
+
+// ReadTools читает реестр режущего инструмента
+func ReadTools(srv *sheets.Service, cfg config.Config) ([]Tool, error) {
+	rows, err := readRows(srv, cfg.SpreadsheetID, sheetRange(cfg.ToolsSheet, "A2:G"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tools: %v", err)
+	}
+
+	tools := make([]Tool, 0, len(rows))
+	for i, row := range rows {
+		if cell(row, 0) == "" {
+			continue // Пропускаем пустые строки
+		}
+
+		life, errLife := parseOptionalNumber(cell(row, 4))
+		remaining, errRemaining := parseOptionalNumber(cell(row, 5))
+		if errLife != nil || errRemaining != nil {
+			log.Printf("Skipping tool in row %d: invalid life values", i+2)
+			continue
+		}
+
+		tool := Tool{
+			Row:          i + 2,
+			ID:           cell(row, 0),
+			Name:         cell(row, 1),
+			Machine:      cell(row, 2),
+			Operation:    cell(row, 3),
+			ExpectedLife: int(life),
+			Remaining:    int(remaining),
+		}
+		if installed, err := utils.ParseDateTime(cell(row, 6)); err == nil {
+			tool.Installed = installed
+		}
+		tools = append(tools, tool)
+	}
+	return tools, nil
+}
+
+// FindTool ищет инструмент по идентификатору, возвращает nil, если инструмент не найден
+func FindTool(srv *sheets.Service, cfg config.Config, id string) (*Tool, error) {
+	tools, err := ReadTools(srv, cfg)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range tools {
+		if t.ID == id {
+			return &t, nil
+		}
+	}
+	return nil, nil
+}
+
+// AppendTool добавляет инструмент в реестр
+func AppendTool(srv *sheets.Service, cfg config.Config, t Tool) error {
+	row, err := appendRow(srv, cfg.SpreadsheetID, cfg.ToolsSheet, toolValues(t))
+	if err != nil {
+		return err
+	}
+	log.Printf("Tool %s written to row %d", t.ID, row)
+	return nil
+}
+
+// ChangeTool фиксирует замену инструмента id: записывает ее в журнал и восстанавливает остаток стойкости
+// Инструмент читается под toolMu, чтобы в журнал попал остаток с учетом одновременного списания стойкости.
+// Если инструмента нет в реестре, возвращает ErrToolNotFound
+func ChangeTool(srv *sheets.Service, cfg config.Config, id, reason, fullName string) error {
+	toolMu.Lock()
+	defer toolMu.Unlock()
+
+	tool, err := FindTool(srv, cfg, id)
+	if err != nil {
+		return err
+	}
+	if tool == nil {
+		return ErrToolNotFound
+	}
+	t := *tool
+
+	change := ToolChange{
+		Time:      time.Now(),
+		ToolID:    t.ID,
+		Machine:   t.Machine,
+		Reason:    reason,
+		Remaining: t.Remaining,
+		FullName:  fullName,
+	}
+	values := []interface{}{
+		change.Time.Format(dateTimeFormat),
+		change.ToolID,
+		change.Machine,
+		change.Reason,
+		change.Remaining,
+		change.FullName,
+	}
+	if _, err := appendRow(srv, cfg.SpreadsheetID, cfg.ToolChangeSheet, values); err != nil {
+		return fmt.Errorf("failed to write tool change: %v", err)
+	}
+
+	// Новый инструмент начинает работу с полной стойкостью
+	t.Remaining = t.ExpectedLife
+	t.Installed = change.Time
+	if err := updateRow(srv, cfg.SpreadsheetID, cfg.ToolsSheet, t.Row, toolValues(t)); err != nil {
+		return err
+	}
+	log.Printf("Tool %s changed on machine %s", t.ID, t.Machine)
+	return nil
+}
+
+// ConsumeToolLife списывает стойкость инструмента, работавшего на станке при выполнении операции,
+// и возвращает инструмент с обновленным остатком
+func ConsumeToolLife(srv *sheets.Service, cfg config.Config, machine, operation string, parts int) ([]Tool, error) {
+	toolMu.Lock()
+	defer toolMu.Unlock()
+
+	tools, err := ReadTools(srv, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	used := make([]Tool, 0)
+	for _, t := range tools {
+		if !t.Applies(machine, operation) {
+			continue
+		}
+		t.Remaining -= parts
+		if err := updateRow(srv, cfg.SpreadsheetID, cfg.ToolsSheet, t.Row, toolValues(t)); err != nil {
+			return used, fmt.Errorf("failed to update tool %s: %v", t.ID, err)
+		}
+		used = append(used, t)
+	}
+	return used, nil
+}
+
+// toolValues формирует значения строки листа инструмента
+func toolValues(t Tool) []interface{} {
+	installed := ""
+	if !t.Installed.IsZero() {
+		installed = t.Installed.Format(dateTimeFormat)
+	}
+	return []interface{}{t.ID, t.Name, t.Machine, t.Operation, t.ExpectedLife, t.Remaining, installed}
+}
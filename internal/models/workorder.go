@@ -0,0 +1,133 @@
+package models
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"google.golang.org/api/sheets/v4"
+
+	"github.com/sergekovalev/siberia/internal/config"
+	"github.com/sergekovalev/siberia/internal/utils"
+)
+
+// Статусы заказа (партии)
+const (
+	WorkOrderOpen       = "open"        // Открыт, производство не начато
+	WorkOrderInProgress = "in_progress" // В работе
+	WorkOrderCompleted  = "completed"   // Выполнен
+	WorkOrderCancelled  = "cancelled"   // Отменен
+)
+
+// ValidWorkOrderStatus проверяет, что статус заказа допустим
+func ValidWorkOrderStatus(status string) bool {
+	switch status {
+	case WorkOrderOpen, WorkOrderInProgress, WorkOrderCompleted, WorkOrderCancelled:
+		return true
+	}
+	return false
+}
+
+// WorkOrder представляет заказ на изготовление партии деталей
+// Номер заказа одновременно является номером партии в записях о производстве.
+// Лист заказов: A - номер, B - деталь, C - количество, D - срок, E - статус, F - дата создания
+type WorkOrder struct {
+	Row      int    `json:"row,omitempty"` // Номер строки на листе заказов
+	Number   string `json:"number"`        // Номер заказа (партии)
+	Part     string `json:"part"`          // Деталь
+	Quantity int    `json:"quantity"`      // Количество деталей в партии
+	DueDate  string `json:"dueDate"`       // Срок выполнения (YYYY-MM-DD)
+	Status   string `json:"status"`        // Статус заказа
+	Created  string `json:"created"`       // Дата создания (YYYY-MM-DD)
+}
+
+// Active проверяет, что по заказу можно вносить записи о производстве
+func (wo WorkOrder) Active() bool {
+	return wo.Status == WorkOrderOpen || wo.Status == WorkOrderInProgress
+}
+
+// ReadWorkOrders читает заказы с листа заказов
+func ReadWorkOrders(srv *sheets.Service, cfg config.Config) ([]WorkOrder, error) {
+	rows, err := readRows(srv, cfg.SpreadsheetID, sheetRange(cfg.WorkOrdersSheet, "A2:F"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read work orders: %v", err)
+	}
+
+	orders := make([]WorkOrder, 0, len(rows))
+	for i, row := range rows {
+		if cell(row, 0) == "" {
+			continue // Пропускаем пустые строки
+		}
+
+		quantity, err := parseOptionalNumber(cell(row, 2))
+		if err != nil {
+			log.Printf("Skipping work order in row %d: invalid quantity: %v", i+2, err)
+			continue
+		}
+
+		orders = append(orders, WorkOrder{
+			Row:      i + 2,
+			Number:   cell(row, 0),
+			Part:     cell(row, 1),
+			Quantity: int(quantity),
+			DueDate:  normalizeDate(cell(row, 3)),
+			Status:   cell(row, 4),
+			Created:  normalizeDate(cell(row, 5)),
+		})
+	}
+	return orders, nil
+}
+
+// FindWorkOrder ищет заказ по номеру, возвращает nil, если заказ не найден
+func FindWorkOrder(srv *sheets.Service, cfg config.Config, number string) (*WorkOrder, error) {
+	orders, err := ReadWorkOrders(srv, cfg)
+	if err != nil {
+		return nil, err
+	}
+	for _, wo := range orders {
+		if wo.Number == number {
+			return &wo, nil
+		}
+	}
+	return nil, nil
+}
+
+// AppendWorkOrder добавляет новый заказ на лист заказов
+func AppendWorkOrder(srv *sheets.Service, cfg config.Config, wo WorkOrder) error {
+	if wo.Status == "" {
+		wo.Status = WorkOrderOpen
+	}
+	if wo.Created == "" {
+		wo.Created = time.Now().Format("2006-01-02")
+	}
+
+	row, err := appendRow(srv, cfg.SpreadsheetID, cfg.WorkOrdersSheet, workOrderValues(wo))
+	if err != nil {
+		return err
+	}
+	log.Printf("Work order %s written to row %d", wo.Number, row)
+	return nil
+}
+
+// UpdateWorkOrderStatus меняет статус заказа в его строке на листе заказов
+func UpdateWorkOrderStatus(srv *sheets.Service, cfg config.Config, wo WorkOrder, status string) error {
+	wo.Status = status
+	if err := updateRow(srv, cfg.SpreadsheetID, cfg.WorkOrdersSheet, wo.Row, workOrderValues(wo)); err != nil {
+		return err
+	}
+	log.Printf("Work order %s status changed to %s", wo.Number, status)
+	return nil
+}
+
+// workOrderValues формирует значения строки листа заказов
+func workOrderValues(wo WorkOrder) []interface{} {
+	return []interface{}{wo.Number, wo.Part, wo.Quantity, wo.DueDate, wo.Status, wo.Created}
+}
+
+// normalizeDate приводит дату из таблицы к формату YYYY-MM-DD, нераспознанное значение возвращается как есть
+func normalizeDate(s string) string {
+	if date, err := utils.ParseDate(s); err == nil {
+		return date.Format("2006-01-02")
+	}
+	return s
+}
@@ -0,0 +1,84 @@
+package reports
+
+import (
+	"sort"
+	"time"
+
+	"github.com/sergekovalev/siberia/internal/models"
+)
+
+// DefectRow содержит брак по операции за период с учетом доработки
+type DefectRow struct {
+	Period           string  `json:"period"`           // Ключ периода
+	PartAndOperation string  `json:"partAndOperation"` // Операция, на которой допущен брак
+	TotalParts       int     `json:"totalParts"`       // Обработано деталей
+	Defective        int     `json:"defective"`        // Брак по записям о производстве
+	Reworked         int     `json:"reworked"`         // Отдано в доработку
+	Fixed            int     `json:"fixed"`            // Исправлено доработкой
+	Scrapped         int     `json:"scrapped"`         // Списано после доработки
+	NetScrap         int     `json:"netScrap"`         // Чистый брак: брак минус исправленные
+	DefectRate       float64 `json:"defectRate"`       // Доля брака, %
+	NetScrapRate     float64 `json:"netScrapRate"`     // Доля чистого брака, %
+}
+
+// Defects рассчитывает брак по операциям за период с учетом доработки
+// Доработка относится к периоду исходной записи о производстве, а если ссылки на запись нет -
+// к дате доработки
+func Defects(records []models.ProductionRecord, rework []models.ReworkData, from, to time.Time, group string) []DefectRow {
+	rows := make(map[rowKey]*DefectRow)
+	get := func(period, operation string) *DefectRow {
+		k := rowKey{period: period, key: operation}
+		if rows[k] == nil {
+			rows[k] = &DefectRow{Period: period, PartAndOperation: operation}
+		}
+		return rows[k]
+	}
+
+	recordDates := make(map[int]time.Time, len(records))
+	for _, rec := range records {
+		recordDates[rec.Row] = rec.Date
+		if !InRange(rec.Date, from, to) {
+			continue
+		}
+		row := get(PeriodKey(rec.Date, group), rec.PartAndOperation)
+		row.TotalParts += rec.TotalParts
+		row.Defective += rec.Defective
+	}
+
+	for _, rw := range rework {
+		date, ok := recordDates[rw.ProductionRow]
+		if rw.ProductionRow == 0 || !ok {
+			var err error
+			if date, err = time.Parse("2006-01-02", rw.Date); err != nil {
+				continue
+			}
+		}
+		if !InRange(date, from, to) {
+			continue
+		}
+		row := get(PeriodKey(date, group), rw.PartAndOperation)
+		row.Reworked += rw.Quantity
+		row.Fixed += rw.Fixed
+		row.Scrapped += rw.Scrapped
+	}
+
+	result := make([]DefectRow, 0, len(rows))
+	for _, row := range rows {
+		row.NetScrap = row.Defective - row.Fixed
+		if row.NetScrap < 0 {
+			row.NetScrap = 0
+		}
+		if row.TotalParts > 0 {
+			row.DefectRate = round2(float64(row.Defective) * 100 / float64(row.TotalParts))
+			row.NetScrapRate = round2(float64(row.NetScrap) * 100 / float64(row.TotalParts))
+		}
+		result = append(result, *row)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Period != result[j].Period {
+			return result[i].Period < result[j].Period
+		}
+		return result[i].PartAndOperation < result[j].PartAndOperation
+	})
+	return result
+}
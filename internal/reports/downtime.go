@@ -0,0 +1,95 @@
+package reports
+
+import (
+	"sort"
+	"time"
+
+	"github.com/sergekovalev/siberia/internal/config"
+	"github.com/sergekovalev/siberia/internal/models"
+)
+
+// DowntimeRow содержит суммарный простой станка по причине за смену
+type DowntimeRow struct {
+	ShiftDate  string  `json:"shiftDate"`  // Дата начала смены
+	Shift      string  `json:"shift"`      // Название смены
+	Machine    string  `json:"machine"`    // Идентификатор станка
+	Reason     string  `json:"reason"`     // Код причины
+	ReasonName string  `json:"reasonName"` // Название причины
+	Minutes    float64 `json:"minutes"`    // Длительность простоя в смене, мин
+	Events     int     `json:"events"`     // Количество случаев простоя
+}
+
+// downtimeKey идентифицирует строку отчета о простоях
+type downtimeKey struct {
+	shiftDate string
+	shift     string
+	machine   string
+	reason    string
+}
+
+// DowntimeByShift рассчитывает минуты простоя по станкам и причинам в каждой смене периода
+// Незавершенный простой считается до момента now. Время простоя вне смен не учитывается,
+// так как в это время оборудование и так не должно работать
+func DowntimeByShift(events []models.Downtime, shifts []config.Shift, reasons []config.DowntimeReason, from, to, now time.Time) []DowntimeRow {
+	reasonNames := make(map[string]string, len(reasons))
+	for _, reason := range reasons {
+		reasonNames[reason.Code] = reason.Name
+	}
+
+	// Порядок смен в конфигурации используется для сортировки отчета
+	shiftOrder := make(map[string]int, len(shifts))
+	for i, shift := range shifts {
+		shiftOrder[shift.Name] = i
+	}
+
+	windows := ShiftWindows(shifts, from, to)
+	rows := make(map[downtimeKey]*DowntimeRow)
+	for _, event := range events {
+		end := now
+		if event.End != nil {
+			end = *event.End
+		}
+
+		// Распределяем простой по сменам, с которыми он пересекается
+		for _, w := range windows {
+			minutes := overlapMinutes(event.Start, end, w.Start, w.End)
+			if minutes <= 0 {
+				continue
+			}
+			k := downtimeKey{shiftDate: w.Date, shift: w.Name, machine: event.Machine, reason: event.Reason}
+			row := rows[k]
+			if row == nil {
+				row = &DowntimeRow{
+					ShiftDate:  w.Date,
+					Shift:      w.Name,
+					Machine:    event.Machine,
+					Reason:     event.Reason,
+					ReasonName: reasonNames[event.Reason],
+				}
+				rows[k] = row
+			}
+			row.Minutes += minutes
+			row.Events++
+		}
+	}
+
+	result := make([]DowntimeRow, 0, len(rows))
+	for _, row := range rows {
+		row.Minutes = round2(row.Minutes)
+		result = append(result, *row)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if a.ShiftDate != b.ShiftDate {
+			return a.ShiftDate < b.ShiftDate
+		}
+		if a.Shift != b.Shift {
+			return shiftOrder[a.Shift] < shiftOrder[b.Shift]
+		}
+		if a.Machine != b.Machine {
+			return a.Machine < b.Machine
+		}
+		return a.Reason < b.Reason
+	})
+	return result
+}
@@ -0,0 +1,147 @@
+package reports
+
+import (
+	"sort"
+	"time"
+
+	"github.com/sergekovalev/siberia/internal/models"
+)
+
+// EfficiencyRow содержит показатели выработки сотрудника или операции за период
+type EfficiencyRow struct {
+	Period      string  `json:"period"`      // Ключ периода
+	Key         string  `json:"key"`         // Сотрудник или операция
+	GoodParts   int     `json:"goodParts"`   // Количество годных деталей
+	EarnedHours float64 `json:"earnedHours"` // Нормо-часы за годные детали
+	WorkedHours float64 `json:"workedHours"` // Отработанные часы по табелю
+	Efficiency  float64 `json:"efficiency"`  // Выработка: нормо-часы / отработанные часы
+}
+
+// EfficiencyReport представляет отчет о выработке по сотрудникам и операциям
+type EfficiencyReport struct {
+	From             string          `json:"from"`
+	To               string          `json:"to"`
+	Group            string          `json:"group"`
+	ByEmployee       []EfficiencyRow `json:"byEmployee"`
+	ByOperation      []EfficiencyRow `json:"byOperation"`
+	MissingStandards []string        `json:"missingStandards"` // Операции, для которых не заданы нормы времени
+}
+
+// rowKey идентифицирует строку отчета: период и сотрудник/операция
+type rowKey struct {
+	period string
+	key    string
+}
+
+// employeeDay идентифицирует рабочий день сотрудника
+type employeeDay struct {
+	name string
+	date string
+}
+
+// Efficiency рассчитывает выработку (нормо-часы / отработанные часы) по сотрудникам и операциям
+// Нормо-часы записи = (подготовительное время + годные детали * штучное время) / 60.
+// Табель не содержит разбивки часов по операциям, поэтому часы сотрудника за день распределяются
+// между его операциями пропорционально заработанным нормо-часам
+func Efficiency(records []models.ProductionRecord, hours map[string]map[string]float64, operations map[string]models.Operation, from, to time.Time, group string) EfficiencyReport {
+	employees := make(map[rowKey]*EfficiencyRow)
+	byOperation := make(map[rowKey]*EfficiencyRow)
+	missing := make(map[string]bool)
+
+	// Нормо-часы сотрудника за день: всего и в разрезе операций
+	dayEarned := make(map[employeeDay]float64)
+	dayOperationEarned := make(map[employeeDay]map[string]float64)
+
+	for _, rec := range records {
+		if !InRange(rec.Date, from, to) {
+			continue
+		}
+		period := PeriodKey(rec.Date, group)
+
+		earned := 0.0
+		if op, ok := operations[rec.PartAndOperation]; ok {
+			earned = (op.SetupMinutes + float64(rec.GoodParts)*op.CycleMinutes) / 60
+		} else {
+			missing[rec.PartAndOperation] = true
+		}
+
+		emp := getRow(employees, period, rec.FullName)
+		emp.GoodParts += rec.GoodParts
+		emp.EarnedHours += earned
+
+		op := getRow(byOperation, period, rec.PartAndOperation)
+		op.GoodParts += rec.GoodParts
+		op.EarnedHours += earned
+
+		day := employeeDay{name: rec.FullName, date: rec.Date.Format("2006-01-02")}
+		dayEarned[day] += earned
+		if dayOperationEarned[day] == nil {
+			dayOperationEarned[day] = make(map[string]float64)
+		}
+		dayOperationEarned[day][rec.PartAndOperation] += earned
+	}
+
+	// Добавляем отработанные часы из табеля
+	for name, days := range hours {
+		for dateStr, h := range days {
+			date, err := time.Parse("2006-01-02", dateStr)
+			if err != nil || !InRange(date, from, to) {
+				continue
+			}
+			period := PeriodKey(date, group)
+			getRow(employees, period, name).WorkedHours += h
+
+			// Распределяем часы дня между операциями сотрудника
+			day := employeeDay{name: name, date: dateStr}
+			if dayEarned[day] <= 0 {
+				continue
+			}
+			for opName, earned := range dayOperationEarned[day] {
+				getRow(byOperation, period, opName).WorkedHours += h * earned / dayEarned[day]
+			}
+		}
+	}
+
+	report := EfficiencyReport{
+		From:             from.Format("2006-01-02"),
+		To:               to.Format("2006-01-02"),
+		Group:            group,
+		ByEmployee:       finishRows(employees),
+		ByOperation:      finishRows(byOperation),
+		MissingStandards: make([]string, 0, len(missing)),
+	}
+	for name := range missing {
+		report.MissingStandards = append(report.MissingStandards, name)
+	}
+	sort.Strings(report.MissingStandards)
+	return report
+}
+
+// getRow возвращает строку отчета для периода и ключа, создавая ее при необходимости
+func getRow(rows map[rowKey]*EfficiencyRow, period, key string) *EfficiencyRow {
+	k := rowKey{period: period, key: key}
+	if rows[k] == nil {
+		rows[k] = &EfficiencyRow{Period: period, Key: key}
+	}
+	return rows[k]
+}
+
+// finishRows вычисляет выработку, округляет значения и сортирует строки по периоду и ключу
+func finishRows(rows map[rowKey]*EfficiencyRow) []EfficiencyRow {
+	result := make([]EfficiencyRow, 0, len(rows))
+	for _, row := range rows {
+		if row.WorkedHours > 0 {
+			row.Efficiency = round2(row.EarnedHours / row.WorkedHours)
+		}
+		row.EarnedHours = round2(row.EarnedHours)
+		row.WorkedHours = round2(row.WorkedHours)
+		result = append(result, *row)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Period != result[j].Period {
+			return result[i].Period < result[j].Period
+		}
+		return result[i].Key < result[j].Key
+	})
+	return result
+}
@@ -0,0 +1,61 @@
+package reports
+
+import (
+	"sort"
+	"time"
+
+	"github.com/sergekovalev/siberia/internal/models"
+)
+
+// MachineOutputRow содержит выпуск и брак станка за период
+type MachineOutputRow struct {
+	Period      string  `json:"period"`      // Ключ периода
+	MachineID   string  `json:"machineId"`   // Идентификатор станка (пусто для записей без станка)
+	MachineName string  `json:"machineName"` // Название станка из реестра
+	Workshop    string  `json:"workshop"`    // Участок
+	TotalParts  int     `json:"totalParts"`  // Обработано деталей
+	GoodParts   int     `json:"goodParts"`   // Годные детали
+	Defective   int     `json:"defective"`   // Брак
+	DefectRate  float64 `json:"defectRate"`  // Доля брака, %
+}
+
+// MachineOutput рассчитывает выпуск и долю брака по станкам за период
+func MachineOutput(records []models.ProductionRecord, machines []models.Machine, from, to time.Time, group string) []MachineOutputRow {
+	registry := make(map[string]models.Machine, len(machines))
+	for _, m := range machines {
+		registry[m.ID] = m
+	}
+
+	rows := make(map[rowKey]*MachineOutputRow)
+	for _, rec := range records {
+		if !InRange(rec.Date, from, to) {
+			continue
+		}
+		period := PeriodKey(rec.Date, group)
+		k := rowKey{period: period, key: rec.Machine}
+		row := rows[k]
+		if row == nil {
+			m := registry[rec.Machine]
+			row = &MachineOutputRow{Period: period, MachineID: rec.Machine, MachineName: m.Name, Workshop: m.Workshop}
+			rows[k] = row
+		}
+		row.TotalParts += rec.TotalParts
+		row.GoodParts += rec.GoodParts
+		row.Defective += rec.Defective
+	}
+
+	result := make([]MachineOutputRow, 0, len(rows))
+	for _, row := range rows {
+		if row.TotalParts > 0 {
+			row.DefectRate = round2(float64(row.Defective) * 100 / float64(row.TotalParts))
+		}
+		result = append(result, *row)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Period != result[j].Period {
+			return result[i].Period < result[j].Period
+		}
+		return result[i].MachineID < result[j].MachineID
+	})
+	return result
+}
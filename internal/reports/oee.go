@@ -0,0 +1,187 @@
+package reports
+
+import (
+	"sort"
+	"time"
+
+	"github.com/sergekovalev/siberia/internal/config"
+	"github.com/sergekovalev/siberia/internal/models"
+)
+
+// GroupShift - группировка отчета OEE по сменам
+const GroupShift = "shift"
+
+// OEERow содержит показатели эффективности оборудования (OEE) станка за период
+type OEERow struct {
+	Period           string   `json:"period"`                     // Смена (дата и название), день или неделя
+	Machine          string   `json:"machine"`                    // Идентификатор станка
+	MachineName      string   `json:"machineName"`                // Название станка
+	PlannedMinutes   float64  `json:"plannedMinutes"`             // Плановое время работы (длительность смен)
+	DowntimeMinutes  float64  `json:"downtimeMinutes"`            // Простои в пределах смен
+	RunMinutes       float64  `json:"runMinutes"`                 // Время работы: плановое время минус простои
+	IdealMinutes     float64  `json:"idealMinutes"`               // Нормативное время на все обработанные детали
+	TotalParts       int      `json:"totalParts"`                 // Обработано деталей
+	GoodParts        int      `json:"goodParts"`                  // Годные детали
+	Availability     float64  `json:"availability"`               // Доступность, %
+	Performance      float64  `json:"performance"`                // Производительность, %
+	Quality          float64  `json:"quality"`                    // Качество, %
+	OEE              float64  `json:"oee"`                        // OEE = доступность * производительность * качество, %
+	MissingStandards []string `json:"missingStandards,omitempty"` // Операции без штучного времени (не учтены в производительности)
+}
+
+// OEEInput содержит исходные данные для расчета OEE
+type OEEInput struct {
+	Records    []models.ProductionRecord
+	Downtime   []models.Downtime
+	Operations []models.Operation
+	Machines   []models.Machine
+	Shifts     []config.Shift
+}
+
+// OEE рассчитывает доступность, производительность и качество по станкам за период
+// Плановое время - длительность смен, в которые по станку есть выпуск или простой: смены, в которые
+// станок не работал по плану, не снижают доступность. Текущая смена учитывается до момента now.
+// Записи о производстве без смены относятся к первой смене дня
+func OEE(in OEEInput, from, to, now time.Time, group string) []OEERow {
+	operations := models.OperationsByName(in.Operations)
+	machineNames := make(map[string]string, len(in.Machines))
+	for _, m := range in.Machines {
+		machineNames[m.ID] = m.Name
+	}
+	firstShift := ""
+	if len(in.Shifts) > 0 {
+		firstShift = in.Shifts[0].Name
+	}
+
+	// Группируем записи о производстве по станку и смене
+	type shiftKey struct{ machine, date, shift string }
+	production := make(map[shiftKey][]models.ProductionRecord)
+	for _, rec := range in.Records {
+		if rec.Machine == "" || !InRange(rec.Date, from, to) {
+			continue
+		}
+		shift := rec.Shift
+		if shift == "" {
+			shift = firstShift
+		}
+		k := shiftKey{machine: rec.Machine, date: rec.Date.Format("2006-01-02"), shift: shift}
+		production[k] = append(production[k], rec)
+	}
+
+	machines := make(map[string]bool)
+	for k := range production {
+		machines[k.machine] = true
+	}
+	for _, d := range in.Downtime {
+		machines[d.Machine] = true
+	}
+
+	type accumulator struct {
+		row     *OEERow
+		missing map[string]bool
+	}
+	rows := make(map[rowKey]*accumulator)
+
+	for _, w := range ShiftWindows(in.Shifts, from, to) {
+		if !w.Start.Before(now) {
+			continue // Смена еще не началась
+		}
+		end := w.End
+		if now.Before(end) {
+			end = now // Текущая смена учитывается до настоящего момента
+		}
+
+		for machine := range machines {
+			records := production[shiftKey{machine: machine, date: w.Date, shift: w.Name}]
+
+			// Простои станка в пределах смены
+			downtime := 0.0
+			for _, d := range in.Downtime {
+				if d.Machine != machine {
+					continue
+				}
+				dEnd := now
+				if d.End != nil {
+					dEnd = *d.End
+				}
+				downtime += overlapMinutes(d.Start, dEnd, w.Start, end)
+			}
+			if len(records) == 0 && downtime == 0 {
+				continue // Станок в эту смену не работал
+			}
+
+			var period string
+			switch group {
+			case GroupShift:
+				period = w.Date + " " + w.Name
+			default:
+				date, _ := time.Parse("2006-01-02", w.Date)
+				period = PeriodKey(date, group)
+			}
+
+			k := rowKey{period: period, key: machine}
+			acc := rows[k]
+			if acc == nil {
+				acc = &accumulator{
+					row:     &OEERow{Period: period, Machine: machine, MachineName: machineNames[machine]},
+					missing: make(map[string]bool),
+				}
+				rows[k] = acc
+			}
+			acc.row.PlannedMinutes += end.Sub(w.Start).Minutes()
+			acc.row.DowntimeMinutes += downtime
+
+			for _, rec := range records {
+				acc.row.TotalParts += rec.TotalParts
+				acc.row.GoodParts += rec.GoodParts
+				if op, ok := operations[rec.PartAndOperation]; ok && op.CycleMinutes > 0 {
+					acc.row.IdealMinutes += float64(rec.TotalParts) * op.CycleMinutes
+				} else {
+					acc.missing[rec.PartAndOperation] = true
+				}
+			}
+		}
+	}
+
+	result := make([]OEERow, 0, len(rows))
+	for _, acc := range rows {
+		row := acc.row
+		row.RunMinutes = row.PlannedMinutes - row.DowntimeMinutes
+		if row.RunMinutes < 0 {
+			row.RunMinutes = 0
+		}
+
+		availability, performance, quality := 0.0, 0.0, 0.0
+		if row.PlannedMinutes > 0 {
+			availability = row.RunMinutes / row.PlannedMinutes
+		}
+		if row.RunMinutes > 0 {
+			performance = row.IdealMinutes / row.RunMinutes
+		}
+		if row.TotalParts > 0 {
+			quality = float64(row.GoodParts) / float64(row.TotalParts)
+		}
+
+		row.Availability = round2(availability * 100)
+		row.Performance = round2(performance * 100)
+		row.Quality = round2(quality * 100)
+		row.OEE = round2(availability * performance * quality * 100)
+		row.PlannedMinutes = round2(row.PlannedMinutes)
+		row.DowntimeMinutes = round2(row.DowntimeMinutes)
+		row.RunMinutes = round2(row.RunMinutes)
+		row.IdealMinutes = round2(row.IdealMinutes)
+		for name := range acc.missing {
+			row.MissingStandards = append(row.MissingStandards, name)
+		}
+		sort.Strings(row.MissingStandards)
+		result = append(result, *row)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Period != result[j].Period {
+			return result[i].Period < result[j].Period
+		}
+		return result[i].Machine < result[j].Machine
+	})
+	return result
+}
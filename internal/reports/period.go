@@ -0,0 +1,42 @@
+package reports
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Группировки отчетов по периодам
+const (
+	GroupDay   = "day"   // По дням
+	GroupWeek  = "week"  // По неделям (ISO)
+	GroupMonth = "month" // По месяцам
+)
+
+// ValidGroup проверяет, что группировка по периодам поддерживается
+func ValidGroup(group string) bool {
+	return group == GroupDay || group == GroupWeek || group == GroupMonth
+}
+
+// PeriodKey возвращает ключ периода, к которому относится дата: 2006-01-02, 2006-W01 или 2006-01
+func PeriodKey(date time.Time, group string) string {
+	switch group {
+	case GroupWeek:
+		year, week := date.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	case GroupMonth:
+		return date.Format("2006-01")
+	default:
+		return date.Format("2006-01-02")
+	}
+}
+
+// InRange проверяет, что дата попадает в период [from, to] включительно
+func InRange(date, from, to time.Time) bool {
+	return !date.Before(from) && !date.After(to)
+}
+
+// round2 округляет значение до двух знаков после запятой для вывода в отчетах
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
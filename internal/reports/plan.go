@@ -0,0 +1,99 @@
+package reports
+
+import (
+	"sort"
+	"time"
+
+	"github.com/sergekovalev/siberia/internal/models"
+)
+
+// Статусы выполнения планового задания
+const (
+	PlanUpcoming = "upcoming" // Период еще не начался
+	PlanOnTrack  = "on_track" // Выполнение идет по графику
+	PlanBehind   = "behind"   // Отставание от графика в текущем периоде
+	PlanMissed   = "missed"   // Период закончился, план не выполнен
+	PlanDone     = "done"     // План выполнен
+)
+
+// PlanProgress содержит сравнение планового задания с фактическим выпуском
+type PlanProgress struct {
+	models.PlanTarget
+	From      string  `json:"from"`      // Первый день периода
+	To        string  `json:"to"`        // Последний день периода
+	Actual    int     `json:"actual"`    // Фактически выпущено годных деталей
+	Progress  float64 `json:"progress"`  // Процент выполнения
+	Expected  int     `json:"expected"`  // Ожидаемый выпуск на текущий момент при равномерном выполнении
+	Shortfall int     `json:"shortfall"` // Недовыполнение относительно плана
+	Status    string  `json:"status"`    // Статус выполнения
+	Alert     bool    `json:"alert"`     // Требует внимания мастера
+}
+
+// PlanVsFact сопоставляет плановые задания с фактическим выпуском годных деталей
+// Для задания без сотрудника учитывается выпуск всех сотрудников по операции.
+// Ожидаемый выпуск в текущем периоде считается пропорционально полностью прошедшим дням
+func PlanVsFact(targets []models.PlanTarget, records []models.ProductionRecord, today time.Time) []PlanProgress {
+	result := make([]PlanProgress, 0, len(targets))
+	for _, target := range targets {
+		from, to, err := target.Dates()
+		if err != nil {
+			continue
+		}
+
+		progress := PlanProgress{
+			PlanTarget: target,
+			From:       from.Format("2006-01-02"),
+			To:         to.Format("2006-01-02"),
+		}
+
+		// Суммируем фактический выпуск по операции (и сотруднику) за период задания
+		for _, rec := range records {
+			if rec.PartAndOperation != target.PartAndOperation || !InRange(rec.Date, from, to) {
+				continue
+			}
+			if target.Employee != "" && rec.FullName != target.Employee {
+				continue
+			}
+			progress.Actual += rec.GoodParts
+		}
+
+		if target.Quantity > 0 {
+			progress.Progress = round2(float64(progress.Actual) * 100 / float64(target.Quantity))
+		}
+		if progress.Actual < target.Quantity {
+			progress.Shortfall = target.Quantity - progress.Actual
+		}
+
+		// Определяем статус по дате и ожидаемому выпуску
+		totalDays := int(to.Sub(from).Hours()/24) + 1
+		switch {
+		case progress.Actual >= target.Quantity:
+			progress.Status = PlanDone
+			progress.Expected = target.Quantity
+		case today.After(to):
+			progress.Status = PlanMissed
+			progress.Expected = target.Quantity
+			progress.Alert = true
+		case today.Before(from):
+			progress.Status = PlanUpcoming
+		default:
+			elapsed := int(today.Sub(from).Hours() / 24) // Полностью прошедшие дни
+			progress.Expected = target.Quantity * elapsed / totalDays
+			progress.Status = PlanOnTrack
+			if progress.Actual < progress.Expected {
+				progress.Status = PlanBehind
+				progress.Alert = true
+			}
+		}
+
+		result = append(result, progress)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].From != result[j].From {
+			return result[i].From < result[j].From
+		}
+		return result[i].PartAndOperation < result[j].PartAndOperation
+	})
+	return result
+}
@@ -0,0 +1,285 @@
+package reports
+
+import (
+	"sort"
+	"time"
+
+	"github.com/sergekovalev/siberia/internal/config"
+	"github.com/sergekovalev/siberia/internal/models"
+)
+
+// Разрезы отчета о стоимости брака
+const (
+	ScrapByPart      = "part"
+	ScrapByOperation = "operation"
+	ScrapByEmployee  = "employee"
+	ScrapByReason    = "reason"
+)
+
+// ValidScrapDimension проверяет, что разрез отчета о стоимости брака известен
+func ValidScrapDimension(by string) bool {
+	switch by {
+	case ScrapByPart, ScrapByOperation, ScrapByEmployee, ScrapByReason:
+		return true
+	}
+	return false
+}
+
+// ScrapCostRow содержит стоимость брака за период в выбранном разрезе
+type ScrapCostRow struct {
+	Period       string   `json:"period"`                 // Ключ периода
+	Key          string   `json:"key"`                    // Деталь, операция, сотрудник или код причины брака
+	Name         string   `json:"name,omitempty"`         // Название причины брака
+	ScrapParts   int      `json:"scrapParts"`             // Забраковано деталей (без исправленных доработкой)
+	MaterialCost float64  `json:"materialCost"`           // Стоимость материала, руб
+	LabourCost   float64  `json:"labourCost"`             // Стоимость труда, накопленная до операции брака, руб
+	TotalCost    float64  `json:"totalCost"`              // Полная стоимость брака, руб
+	MissingCosts []string `json:"missingCosts,omitempty"` // Детали и операции без данных о стоимости
+}
+
+// ScrapCostInput содержит исходные данные для расчета стоимости брака
+type ScrapCostInput struct {
+	Records       []models.ProductionRecord
+	Rework        []models.ReworkData
+	Operations    []models.Operation
+	BOM           []models.BOMLine
+	Materials     []models.Material
+	DefectReasons []config.DefectReason
+	LabourRate    float64 // Стоимость нормо-часа для операций без стоимости труда
+}
+
+// unitCost - стоимость одной детали, забракованной на операции
+type unitCost struct {
+	material float64
+	labour   float64
+	missing  []string
+}
+
+// ScrapCost оценивает брак за период по себестоимости, накопленной к операции, на которой деталь забракована:
+// стоимость материала детали плюс стоимость труда всех операций маршрута до этой операции включительно.
+// Детали, исправленные доработкой, в стоимость брака не входят; доработка по партии распределяется
+// между строками выпуска партии на этой операции пропорционально их недоработанному браку
+func ScrapCost(in ScrapCostInput, from, to time.Time, group, by string) []ScrapCostRow {
+	operations := models.OperationsByName(in.Operations)
+	routings := models.Routings(in.Operations)
+	reasonNames := make(map[string]string, len(in.DefectReasons))
+	for _, r := range in.DefectReasons {
+		reasonNames[r.Code] = r.Name
+	}
+
+	// Стоимость материала одной детали по спецификации
+	prices := make(map[string]float64, len(in.Materials))
+	for _, m := range in.Materials {
+		prices[m.Code] = m.Price
+	}
+	materialCost := make(map[string]float64)
+	for _, line := range in.BOM {
+		materialCost[line.Part] += line.PerPiece * prices[line.Material]
+	}
+
+	// Стоимость труда на операции: из справочника или по штучному времени и стоимости нормо-часа
+	labour := func(op models.Operation) (float64, bool) {
+		if op.LabourCost > 0 {
+			return op.LabourCost, true
+		}
+		if op.CycleMinutes > 0 && in.LabourRate > 0 {
+			return op.CycleMinutes / 60 * in.LabourRate, true
+		}
+		return 0, false
+	}
+
+	costs := make(map[string]unitCost)
+	costOf := func(name string) unitCost {
+		if c, ok := costs[name]; ok {
+			return c
+		}
+		var c unitCost
+		op, ok := operations[name]
+		if !ok {
+			c.missing = append(c.missing, name)
+			costs[name] = c
+			return c
+		}
+
+		// Операции маршрута до текущей включительно; операция вне маршрута учитывается одна
+		steps := []models.Operation{op}
+		if op.Part != "" && op.Sequence > 0 {
+			steps = steps[:0]
+			for _, step := range routings[op.Part] {
+				if step.Sequence <= op.Sequence {
+					steps = append(steps, step)
+				}
+			}
+		}
+		for _, step := range steps {
+			cost, ok := labour(step)
+			if !ok {
+				c.missing = append(c.missing, step.Name)
+			}
+			c.labour += cost
+		}
+
+		if op.Part != "" && materialCost[op.Part] > 0 {
+			c.material = materialCost[op.Part]
+		} else {
+			c.missing = append(c.missing, partOf(op))
+		}
+		costs[name] = c
+		return c
+	}
+
+	// Исправленные доработкой детали по строкам выпуска, включая доработки по партии
+	fixed := fixedByRow(in.Records, in.Rework)
+
+	type accumulator struct {
+		row     *ScrapCostRow
+		missing map[string]bool
+	}
+	rows := make(map[rowKey]*accumulator)
+	for _, rec := range in.Records {
+		if !InRange(rec.Date, from, to) {
+			continue
+		}
+		scrap := rec.Defective - fixed[rec.Row]
+		if scrap <= 0 {
+			continue
+		}
+
+		cost := costOf(rec.PartAndOperation)
+		var key string
+		switch by {
+		case ScrapByOperation:
+			key = rec.PartAndOperation
+		case ScrapByEmployee:
+			key = rec.FullName
+		case ScrapByReason:
+			key = rec.DefectReason
+		default:
+			key = partOf(operations[rec.PartAndOperation])
+			if key == "" {
+				key = rec.PartAndOperation // Операция не из справочника
+			}
+		}
+
+		k := rowKey{period: PeriodKey(rec.Date, group), key: key}
+		acc := rows[k]
+		if acc == nil {
+			acc = &accumulator{row: &ScrapCostRow{Period: k.period, Key: key}, missing: make(map[string]bool)}
+			if by == ScrapByReason {
+				acc.row.Name = reasonNames[key]
+			}
+			rows[k] = acc
+		}
+		acc.row.ScrapParts += scrap
+		acc.row.MaterialCost += float64(scrap) * cost.material
+		acc.row.LabourCost += float64(scrap) * cost.labour
+		for _, name := range cost.missing {
+			acc.missing[name] = true
+		}
+	}
+
+	result := make([]ScrapCostRow, 0, len(rows))
+	for _, acc := range rows {
+		row := acc.row
+		row.TotalCost = round2(row.MaterialCost + row.LabourCost)
+		row.MaterialCost = round2(row.MaterialCost)
+		row.LabourCost = round2(row.LabourCost)
+		for name := range acc.missing {
+			row.MissingCosts = append(row.MissingCosts, name)
+		}
+		sort.Strings(row.MissingCosts)
+		result = append(result, *row)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Period != result[j].Period {
+			return result[i].Period < result[j].Period
+		}
+		return result[i].TotalCost > result[j].TotalCost // Самые дорогие потери - первыми
+	})
+	return result
+}
+
+// lotOperation - партия и операция, к которым относится доработка без ссылки на строку выпуска
+type lotOperation struct {
+	lot, operation string
+}
+
+// fixedByRow возвращает число исправленных доработкой деталей по номерам строк выпуска
+// Доработка по строке относится к ней целиком. Доработка по партии делится между строками партии
+// на той же операции пропорционально браку, оставшемуся после доработок по строкам; остаток от
+// округления достается строкам с наибольшей дробной частью (при равенстве - более ранним)
+func fixedByRow(records []models.ProductionRecord, rework []models.ReworkData) map[int]int {
+	fixed := make(map[int]int)
+	lotFixed := make(map[lotOperation]int)
+	for _, rw := range rework {
+		switch {
+		case rw.ProductionRow > 0:
+			fixed[rw.ProductionRow] += rw.Fixed
+		case rw.Lot != "":
+			lotFixed[lotOperation{rw.Lot, rw.PartAndOperation}] += rw.Fixed
+		}
+	}
+	if len(lotFixed) == 0 {
+		return fixed
+	}
+
+	type share struct {
+		row       int
+		remaining int
+	}
+	shares := make(map[lotOperation][]share)
+	for _, rec := range records {
+		k := lotOperation{rec.Lot, rec.PartAndOperation}
+		if _, ok := lotFixed[k]; !ok {
+			continue
+		}
+		if remaining := rec.Defective - fixed[rec.Row]; remaining > 0 {
+			shares[k] = append(shares[k], share{row: rec.Row, remaining: remaining})
+		}
+	}
+
+	for k, total := range lotFixed {
+		list := shares[k]
+		sort.Slice(list, func(i, j int) bool { return list[i].row < list[j].row })
+		remaining := 0
+		for _, sh := range list {
+			remaining += sh.remaining
+		}
+		if remaining == 0 {
+			continue
+		}
+		if total >= remaining {
+			for _, sh := range list {
+				fixed[sh.row] += sh.remaining
+			}
+			continue
+		}
+
+		// Целые доли и остатки от деления для распределения округления
+		fractions := make([]int, len(list))
+		left := total
+		for i, sh := range list {
+			part := total * sh.remaining / remaining
+			fractions[i] = total * sh.remaining % remaining
+			fixed[sh.row] += part
+			left -= part
+		}
+		order := make([]int, len(list))
+		for i := range order {
+			order[i] = i
+		}
+		sort.SliceStable(order, func(a, b int) bool { return fractions[order[a]] > fractions[order[b]] })
+		for _, i := range order[:left] {
+			fixed[list[i].row]++
+		}
+	}
+	return fixed
+}
+
+// partOf возвращает деталь операции, а для операции вне маршрута - ее название
+func partOf(op models.Operation) string {
+	if op.Part != "" {
+		return op.Part
+	}
+	return op.Name
+}
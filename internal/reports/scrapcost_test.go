@@ -0,0 +1,148 @@
+package reports
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sergekovalev/siberia/internal/models"
+)
+
+func TestScrapCost(t *testing.T) {
+	date := time.Date(2026, 9, 10, 0, 0, 0, 0, time.UTC)
+	const (
+		turning = "Вал 01 - токарная"
+		milling = "Вал 01 - фрезерная"
+	)
+	in := ScrapCostInput{
+		Operations: []models.Operation{
+			{Name: turning, Part: "Вал 01", Sequence: 1, LabourCost: 10},
+			{Name: milling, Part: "Вал 01", Sequence: 2, LabourCost: 20},
+			{Name: "Зачистка", CycleMinutes: 6},
+		},
+		BOM:       []models.BOMLine{{Part: "Вал 01", Material: "M1", PerPiece: 2}},
+		Materials: []models.Material{{Code: "M1", Price: 50}},
+		Records: []models.ProductionRecord{
+			{Row: 2, Date: date, FullName: "Иванов", PartAndOperation: turning, Lot: "P-1", Defective: 4, DefectReason: "D1"},
+			{Row: 3, Date: date, FullName: "Петров", PartAndOperation: milling, Lot: "P-1", Defective: 6, DefectReason: "D2"},
+			{Row: 4, Date: date, FullName: "Иванов", PartAndOperation: milling, Lot: "P-1", Defective: 2, DefectReason: "D2"},
+			{Row: 5, Date: date, FullName: "Сидоров", PartAndOperation: "Зачистка", Defective: 1},
+			{Row: 6, Date: date.AddDate(0, -1, 0), PartAndOperation: turning, Defective: 100}, // Вне периода
+		},
+		Rework: []models.ReworkData{
+			{ProductionRow: 2, Lot: "P-1", PartAndOperation: turning, Quantity: 1, Fixed: 1},
+			{Lot: "P-1", PartAndOperation: milling, Quantity: 5, Fixed: 4, Scrapped: 1}, // Делится 3:1 между строками 3 и 4
+		},
+		LabourRate: 600,
+	}
+
+	tests := []struct {
+		name  string
+		by    string
+		rate  float64
+		rows  map[string]ScrapCostRow // Ключ разреза -> ожидаемая строка (без периода)
+		order []string
+	}{
+		{
+			name: "по операциям", by: ScrapByOperation, rate: 600,
+			rows: map[string]ScrapCostRow{
+				milling:    {ScrapParts: 4, MaterialCost: 400, LabourCost: 120, TotalCost: 520},
+				turning:    {ScrapParts: 3, MaterialCost: 300, LabourCost: 30, TotalCost: 330},
+				"Зачистка": {ScrapParts: 1, MaterialCost: 0, LabourCost: 60, TotalCost: 60, MissingCosts: []string{"Зачистка"}},
+			},
+			order: []string{milling, turning, "Зачистка"},
+		},
+		{
+			name: "по сотрудникам", by: ScrapByEmployee, rate: 600,
+			rows: map[string]ScrapCostRow{
+				"Иванов":  {ScrapParts: 4, MaterialCost: 400, LabourCost: 60, TotalCost: 460},
+				"Петров":  {ScrapParts: 3, MaterialCost: 300, LabourCost: 90, TotalCost: 390},
+				"Сидоров": {ScrapParts: 1, MaterialCost: 0, LabourCost: 60, TotalCost: 60, MissingCosts: []string{"Зачистка"}},
+			},
+			order: []string{"Иванов", "Петров", "Сидоров"},
+		},
+		{
+			name: "без стоимости нормо-часа", by: ScrapByPart, rate: 0,
+			rows: map[string]ScrapCostRow{
+				"Вал 01":   {ScrapParts: 7, MaterialCost: 700, LabourCost: 150, TotalCost: 850},
+				"Зачистка": {ScrapParts: 1, MaterialCost: 0, LabourCost: 0, TotalCost: 0, MissingCosts: []string{"Зачистка"}},
+			},
+			order: []string{"Вал 01", "Зачистка"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := in
+			input.LabourRate = tt.rate
+			got := ScrapCost(input, date, date, GroupDay, tt.by)
+
+			keys := make([]string, 0, len(got))
+			for _, row := range got {
+				keys = append(keys, row.Key)
+				want, ok := tt.rows[row.Key]
+				if !ok {
+					t.Errorf("unexpected row %q", row.Key)
+					continue
+				}
+				want.Period, want.Key = "2026-09-10", row.Key
+				if !reflect.DeepEqual(row, want) {
+					t.Errorf("row %q = %+v, want %+v", row.Key, row, want)
+				}
+			}
+			if !reflect.DeepEqual(keys, tt.order) {
+				t.Errorf("order = %v, want %v", keys, tt.order)
+			}
+		})
+	}
+}
+
+func TestFixedByRow(t *testing.T) {
+	const operation = "Вал 01 - фрезерная"
+	records := []models.ProductionRecord{
+		{Row: 2, PartAndOperation: operation, Lot: "P-1", Defective: 2},
+		{Row: 3, PartAndOperation: operation, Lot: "P-1", Defective: 2},
+		{Row: 4, PartAndOperation: operation, Lot: "P-2", Defective: 3},
+	}
+
+	tests := []struct {
+		name   string
+		rework []models.ReworkData
+		want   map[int]int
+	}{
+		{
+			name:   "доработка по строке",
+			rework: []models.ReworkData{{ProductionRow: 3, Lot: "P-1", PartAndOperation: operation, Fixed: 1}},
+			want:   map[int]int{3: 1},
+		},
+		{
+			name:   "остаток округления достается более ранней строке",
+			rework: []models.ReworkData{{Lot: "P-1", PartAndOperation: operation, Fixed: 3}},
+			want:   map[int]int{2: 2, 3: 1},
+		},
+		{
+			name: "доля считается от брака после доработок по строкам",
+			rework: []models.ReworkData{
+				{ProductionRow: 2, Lot: "P-1", PartAndOperation: operation, Fixed: 2},
+				{Lot: "P-1", PartAndOperation: operation, Fixed: 1},
+			},
+			want: map[int]int{2: 2, 3: 1},
+		},
+		{
+			name:   "исправлено больше брака партии",
+			rework: []models.ReworkData{{Lot: "P-2", PartAndOperation: operation, Fixed: 10}},
+			want:   map[int]int{4: 3},
+		},
+		{
+			name:   "другая операция партии не затрагивается",
+			rework: []models.ReworkData{{Lot: "P-1", PartAndOperation: "Вал 01 - токарная", Fixed: 2}},
+			want:   map[int]int{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fixedByRow(records, tt.rework); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("fixedByRow() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package reports
+
+import (
+	"time"
+
+	"github.com/sergekovalev/siberia/internal/config"
+)
+
+// ShiftWindow описывает смену в конкретный день
+type ShiftWindow struct {
+	Date  string    // Дата начала смены (YYYY-MM-DD)
+	Name  string    // Название смены
+	Start time.Time // Начало смены
+	End   time.Time // Окончание смены
+}
+
+// Minutes возвращает длительность смены в минутах
+func (w ShiftWindow) Minutes() float64 {
+	return w.End.Sub(w.Start).Minutes()
+}
+
+// ShiftWindows возвращает смены, начинающиеся в дни с from по to включительно, в местном часовом поясе
+// Время смен проверяется при загрузке конфигурации
+func ShiftWindows(shifts []config.Shift, from, to time.Time) []ShiftWindow {
+	windows := make([]ShiftWindow, 0)
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		midnight := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.Local)
+		for _, shift := range shifts {
+			start, _ := config.ParseClock(shift.Start)
+			end, _ := config.ParseClock(shift.End)
+			if end <= start {
+				end += 24 * 60 // Смена переходит на следующие сутки
+			}
+			windows = append(windows, ShiftWindow{
+				Date:  day.Format("2006-01-02"),
+				Name:  shift.Name,
+				Start: midnight.Add(time.Duration(start) * time.Minute),
+				End:   midnight.Add(time.Duration(end) * time.Minute),
+			})
+		}
+	}
+	return windows
+}
+
+// ShiftAt возвращает смену, в которую попадает момент времени t
+// Второй результат равен false, если момент приходится на время вне смен
+func ShiftAt(shifts []config.Shift, t time.Time) (ShiftWindow, bool) {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	// Ночная смена предыдущего дня может продолжаться после полуночи
+	for _, w := range ShiftWindows(shifts, day.AddDate(0, 0, -1), day) {
+		if !t.Before(w.Start) && t.Before(w.End) {
+			return w, true
+		}
+	}
+	return ShiftWindow{}, false
+}
+
+// overlapMinutes возвращает длительность пересечения интервалов [aStart, aEnd) и [bStart, bEnd) в минутах
+func overlapMinutes(aStart, aEnd, bStart, bEnd time.Time) float64 {
+	start := aStart
+	if bStart.After(start) {
+		start = bStart
+	}
+	end := aEnd
+	if bEnd.Before(end) {
+		end = bEnd
+	}
+	if !end.After(start) {
+		return 0
+	}
+	return end.Sub(start).Minutes()
+}
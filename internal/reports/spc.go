@@ -0,0 +1,231 @@
+package reports
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/sergekovalev/siberia/internal/models"
+)
+
+// Типы контрольных карт
+const (
+	ChartP = "p" // Доля дефектных деталей в подгруппе
+	ChartU = "u" // Число дефектов на деталь
+)
+
+// Разрезы контрольных карт
+const (
+	SPCByOperation = "operation"
+	SPCByMachine   = "machine"
+)
+
+// Правила Western Electric для выявления нарушений статистической управляемости
+const (
+	RuleBeyond3Sigma = 1 // Точка за пределами 3 сигм
+	Rule2of3Beyond2  = 2 // Две из трех последовательных точек за 2 сигмами с одной стороны
+	Rule4of5Beyond1  = 3 // Четыре из пяти последовательных точек за 1 сигмой с одной стороны
+	Rule8SameSide    = 4 // Восемь последовательных точек по одну сторону от центральной линии
+)
+
+// SPCPoint содержит точку контрольной карты: подгруппу выпуска за период
+type SPCPoint struct {
+	Period     string  `json:"period"`               // Ключ периода подгруппы
+	Sample     int     `json:"sample"`               // Обработано деталей (объем подгруппы)
+	Defects    int     `json:"defects"`              // Дефектных деталей
+	Value      float64 `json:"value"`                // p или u подгруппы
+	UCL        float64 `json:"ucl"`                  // Верхняя контрольная граница
+	LCL        float64 `json:"lcl"`                  // Нижняя контрольная граница
+	Violations []int   `json:"violations,omitempty"` // Номера нарушенных правил Western Electric
+}
+
+// SPCChart содержит контрольную карту по операции или станку
+type SPCChart struct {
+	Key             string     `json:"key"`             // Операция или станок
+	Type            string     `json:"type"`            // Тип карты: p или u
+	Center          float64    `json:"center"`          // Центральная линия
+	BaselineSamples int        `json:"baselineSamples"` // Объем исторических данных, по которым рассчитаны границы
+	Points          []SPCPoint `json:"points"`          // Точки карты в порядке периодов
+	OutOfControl    int        `json:"outOfControl"`    // Количество точек с нарушениями
+}
+
+// ValidChart проверяет тип контрольной карты
+func ValidChart(chart string) bool {
+	return chart == ChartP || chart == ChartU
+}
+
+// ValidSPCDimension проверяет разрез контрольной карты
+func ValidSPCDimension(by string) bool {
+	return by == SPCByOperation || by == SPCByMachine
+}
+
+// SPC строит контрольные карты брака по операциям или станкам за период from-to
+// Центральная линия рассчитывается по истории с baselineFrom до начала периода; если истории нет,
+// границы рассчитываются по самому периоду. Границы переменные и зависят от объема подгруппы.
+// В записях о выпуске учитывается число дефектных деталей, поэтому u-карта считает дефектом
+// каждую бракованную деталь
+func SPC(records []models.ProductionRecord, baselineFrom, from, to time.Time, group, by, chart string) []SPCChart {
+	type counts struct{ sample, defects int }
+	baseline := make(map[string]*counts)
+	subgroups := make(map[string]map[string]*counts)
+
+	baselineTo := from.AddDate(0, 0, -1)
+	for _, rec := range records {
+		key := rec.PartAndOperation
+		if by == SPCByMachine {
+			key = rec.Machine
+		}
+		if key == "" || rec.TotalParts <= 0 {
+			continue
+		}
+
+		switch {
+		case InRange(rec.Date, from, to):
+			if subgroups[key] == nil {
+				subgroups[key] = make(map[string]*counts)
+			}
+			period := PeriodKey(rec.Date, group)
+			c := subgroups[key][period]
+			if c == nil {
+				c = &counts{}
+				subgroups[key][period] = c
+			}
+			c.sample += rec.TotalParts
+			c.defects += rec.Defective
+		case InRange(rec.Date, baselineFrom, baselineTo):
+			c := baseline[key]
+			if c == nil {
+				c = &counts{}
+				baseline[key] = c
+			}
+			c.sample += rec.TotalParts
+			c.defects += rec.Defective
+		}
+	}
+
+	result := make([]SPCChart, 0, len(subgroups))
+	for key, periods := range subgroups {
+		c := SPCChart{Key: key, Type: chart}
+
+		// Центральная линия по истории, а при ее отсутствии - по текущему периоду
+		history := baseline[key]
+		if history == nil || history.sample == 0 {
+			history = &counts{}
+			for _, p := range periods {
+				history.sample += p.sample
+				history.defects += p.defects
+			}
+		}
+		c.BaselineSamples = history.sample
+		center := float64(history.defects) / float64(history.sample)
+
+		keys := make([]string, 0, len(periods))
+		for period := range periods {
+			keys = append(keys, period)
+		}
+		sort.Strings(keys)
+
+		z := make([]float64, len(keys))
+		for i, period := range keys {
+			p := periods[period]
+			value := float64(p.defects) / float64(p.sample)
+
+			var sigma float64
+			if chart == ChartU {
+				sigma = math.Sqrt(center / float64(p.sample))
+			} else {
+				sigma = math.Sqrt(center * (1 - center) / float64(p.sample))
+			}
+			ucl := center + 3*sigma
+			if chart == ChartP && ucl > 1 {
+				ucl = 1
+			}
+			lcl := math.Max(0, center-3*sigma)
+
+			z[i] = zScore(value, center, sigma)
+			c.Points = append(c.Points, SPCPoint{
+				Period:  period,
+				Sample:  p.sample,
+				Defects: p.defects,
+				Value:   round4(value),
+				UCL:     round4(ucl),
+				LCL:     round4(lcl),
+			})
+		}
+
+		for i, rules := range westernElectric(z) {
+			if len(rules) > 0 {
+				c.Points[i].Violations = rules
+				c.OutOfControl++
+			}
+		}
+		c.Center = round4(center)
+		result = append(result, c)
+	}
+
+	// Карты с нарушениями - первыми
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].OutOfControl != result[j].OutOfControl {
+			return result[i].OutOfControl > result[j].OutOfControl
+		}
+		return result[i].Key < result[j].Key
+	})
+	return result
+}
+
+// zScore возвращает отклонение точки от центральной линии в сигмах
+// При нулевой сигме (брака не было или брак сплошной) любое отклонение считается выходом за границы
+func zScore(value, center, sigma float64) float64 {
+	if sigma > 0 {
+		return (value - center) / sigma
+	}
+	switch {
+	case value > center:
+		return math.Inf(1)
+	case value < center:
+		return math.Inf(-1)
+	}
+	return 0
+}
+
+// westernElectric проверяет последовательность отклонений z (в сигмах) по правилам Western Electric
+// и возвращает для каждой точки номера нарушенных правил. Нарушение отмечается на последней точке серии
+func westernElectric(z []float64) [][]int {
+	violations := make([][]int, len(z))
+	for i := range z {
+		if math.Abs(z[i]) > 3 {
+			violations[i] = append(violations[i], RuleBeyond3Sigma)
+		}
+		if beyond(z, i, 3, 2, 2) {
+			violations[i] = append(violations[i], Rule2of3Beyond2)
+		}
+		if beyond(z, i, 5, 4, 1) {
+			violations[i] = append(violations[i], Rule4of5Beyond1)
+		}
+		if beyond(z, i, 8, 8, 0) {
+			violations[i] = append(violations[i], Rule8SameSide)
+		}
+	}
+	return violations
+}
+
+// beyond проверяет, что точка i и еще не менее need-1 точек из window последних лежат дальше limit сигм
+// от центральной линии с той же стороны, что и точка i
+func beyond(z []float64, i, window, need int, limit float64) bool {
+	if i+1 < window || math.Abs(z[i]) <= limit {
+		return false
+	}
+	side := math.Copysign(1, z[i])
+	count := 0
+	for j := i - window + 1; j <= i; j++ {
+		if z[j]*side > limit {
+			count++
+		}
+	}
+	return count >= need
+}
+
+// round4 округляет долю до четырех знаков после запятой
+func round4(v float64) float64 {
+	return math.Round(v*10000) / 10000
+}
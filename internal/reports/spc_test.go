@@ -0,0 +1,126 @@
+package reports
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sergekovalev/siberia/internal/models"
+)
+
+func TestWesternElectric(t *testing.T) {
+	eight := []float64{0.5, 0.5, 0.5, 0.5, 0.5, 0.5, 0.5, 0.5}
+
+	tests := []struct {
+		name string
+		z    []float64
+		want map[int][]int // Номер точки -> нарушенные правила; остальные точки без нарушений
+	}{
+		{"без нарушений", []float64{0, 1, -1, 2, -2}, nil},
+		{"точка за 3 сигмами", []float64{0, 3.5}, map[int][]int{1: {RuleBeyond3Sigma}}},
+		{"точка ниже -3 сигм", []float64{-3.1}, map[int][]int{0: {RuleBeyond3Sigma}}},
+		{"нулевая сигма", []float64{math.Inf(1)}, map[int][]int{0: {RuleBeyond3Sigma}}},
+		{"две из трех за 2 сигмами", []float64{2.5, 0, 2.5}, map[int][]int{2: {Rule2of3Beyond2}}},
+		{"две из трех по разные стороны", []float64{2.5, 0, -2.5}, nil},
+		{"3 сигмы и две из трех", []float64{2.5, 0, 3.5}, map[int][]int{2: {RuleBeyond3Sigma, Rule2of3Beyond2}}},
+		{"четыре из пяти за 1 сигмой", []float64{1.5, 1.5, 0, 1.5, 1.5}, map[int][]int{4: {Rule4of5Beyond1}}},
+		{"восемь по одну сторону", eight, map[int][]int{7: {Rule8SameSide}}},
+		{"семь по одну сторону", eight[:7], nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := westernElectric(tt.z)
+			if len(got) != len(tt.z) {
+				t.Fatalf("len = %d, want %d", len(got), len(tt.z))
+			}
+			for i, rules := range got {
+				if want := tt.want[i]; !reflect.DeepEqual(rules, want) {
+					t.Errorf("point %d: rules = %v, want %v", i, rules, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSPC(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2026, 9, d, 0, 0, 0, 0, time.UTC) }
+	const operation = "Вал 01 - токарная"
+	records := []models.ProductionRecord{
+		// История: 1000 деталей, 2% брака
+		{Date: day(1), PartAndOperation: operation, Machine: "CNC-1", TotalParts: 600, Defective: 12},
+		{Date: day(2), PartAndOperation: operation, Machine: "CNC-1", TotalParts: 400, Defective: 8},
+		// Период карты
+		{Date: day(10), PartAndOperation: operation, Machine: "CNC-1", TotalParts: 100, Defective: 2},
+		{Date: day(11), PartAndOperation: operation, Machine: "CNC-2", TotalParts: 100, Defective: 20},
+		{Date: day(11), PartAndOperation: "", Machine: "CNC-2", TotalParts: 100, Defective: 50}, // Без операции не учитывается
+	}
+
+	tests := []struct {
+		name         string
+		by, chart    string
+		baselineFrom time.Time
+		key          string
+		center       float64
+		baseline     int
+		ucl, lcl     []float64
+		violations   [][]int
+	}{
+		{
+			name: "p-карта по истории", by: SPCByOperation, chart: ChartP, baselineFrom: day(1),
+			key: operation, center: 0.02, baseline: 1000,
+			ucl:        []float64{0.062, 0.062},
+			lcl:        []float64{0, 0},
+			violations: [][]int{nil, {RuleBeyond3Sigma}},
+		},
+		{
+			name: "p-карта без истории", by: SPCByOperation, chart: ChartP, baselineFrom: day(5),
+			key: operation, center: 0.11, baseline: 200,
+			ucl:        []float64{0.2039, 0.2039},
+			lcl:        []float64{0.0161, 0.0161},
+			violations: [][]int{nil, nil},
+		},
+		{
+			name: "u-карта по станку", by: SPCByMachine, chart: ChartU, baselineFrom: day(1),
+			key: "CNC-1", center: 0.02, baseline: 1000,
+			ucl:        []float64{0.0624},
+			lcl:        []float64{0},
+			violations: [][]int{nil},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			charts := SPC(records, tt.baselineFrom, day(10), day(11), GroupDay, tt.by, tt.chart)
+			var c *SPCChart
+			for i := range charts {
+				if charts[i].Key == tt.key {
+					c = &charts[i]
+				}
+			}
+			if c == nil {
+				t.Fatalf("chart %q not found in %+v", tt.key, charts)
+			}
+			if c.Center != tt.center || c.BaselineSamples != tt.baseline {
+				t.Errorf("center = %v, baseline = %d, want %v and %d", c.Center, c.BaselineSamples, tt.center, tt.baseline)
+			}
+			if len(c.Points) != len(tt.ucl) {
+				t.Fatalf("points = %d, want %d", len(c.Points), len(tt.ucl))
+			}
+			outOfControl := 0
+			for i, p := range c.Points {
+				if p.UCL != tt.ucl[i] || p.LCL != tt.lcl[i] {
+					t.Errorf("point %d: UCL = %v, LCL = %v, want %v and %v", i, p.UCL, p.LCL, tt.ucl[i], tt.lcl[i])
+				}
+				if !reflect.DeepEqual(p.Violations, tt.violations[i]) {
+					t.Errorf("point %d: violations = %v, want %v", i, p.Violations, tt.violations[i])
+				}
+				if len(p.Violations) > 0 {
+					outOfControl++
+				}
+			}
+			if c.OutOfControl != outOfControl {
+				t.Errorf("outOfControl = %d, want %d", c.OutOfControl, outOfControl)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package reports
+
+import (
+	"sort"
+
+	"github.com/sergekovalev/siberia/internal/models"
+)
+
+// TraceEntry представляет одну запись о производстве в истории партии
+type TraceEntry struct {
+	Row              int    `json:"row"`              // Номер строки на листе выпуска
+	Date             string `json:"date"`             // Дата производства
+	Operator         string `json:"operator"`         // Сотрудник
+	PartAndOperation string `json:"partAndOperation"` // Деталь и операция
+	TotalParts       int    `json:"totalParts"`       // Общее количество деталей
+	Defective        int    `json:"defective"`        // Количество брака
+	GoodParts        int    `json:"goodParts"`        // Количество годных деталей
+	Notes            string `json:"notes"`            // Примечания
+}
+
+// LotOperation содержит итоги партии по одной операции
+type LotOperation struct {
+	PartAndOperation string   `json:"partAndOperation"` // Деталь и операция
+	Operators        []string `json:"operators"`        // Сотрудники, выполнявшие операцию
+	FirstDate        string   `json:"firstDate"`        // Дата первой записи
+	LastDate         string   `json:"lastDate"`         // Дата последней записи
+	TotalParts       int      `json:"totalParts"`       // Общее количество деталей
+	Defective        int      `json:"defective"`        // Количество брака
+	GoodParts        int      `json:"goodParts"`        // Количество годных деталей
+}
+
+// LotTrace представляет историю изготовления партии
+type LotTrace struct {
+	Lot            string            `json:"lot"`            // Номер партии
+	WorkOrder      *models.WorkOrder `json:"workOrder"`      // Заказ (nil, если заказ не найден)
+	Entries        []TraceEntry      `json:"entries"`        // Все записи о производстве по партии
+	Operations     []LotOperation    `json:"operations"`     // Итоги по операциям
+	TotalDefective int               `json:"totalDefective"` // Общее количество брака по партии
+}
+
+// TraceLot собирает все записи о производстве по номеру партии
+func TraceLot(lot string, wo *models.WorkOrder, records []models.ProductionRecord) LotTrace {
+	trace := LotTrace{Lot: lot, WorkOrder: wo, Entries: []TraceEntry{}, Operations: []LotOperation{}}
+	operations := make(map[string]*LotOperation)
+	operators := make(map[string]map[string]bool)
+
+	for _, rec := range records {
+		if rec.Lot != lot {
+			continue
+		}
+		date := rec.Date.Format("2006-01-02")
+		trace.Entries = append(trace.Entries, TraceEntry{
+			Row:              rec.Row,
+			Date:             date,
+			Operator:         rec.FullName,
+			PartAndOperation: rec.PartAndOperation,
+			TotalParts:       rec.TotalParts,
+			Defective:        rec.Defective,
+			GoodParts:        rec.GoodParts,
+			Notes:            rec.Notes,
+		})
+		trace.TotalDefective += rec.Defective
+
+		// Накапливаем итоги по операции
+		op := operations[rec.PartAndOperation]
+		if op == nil {
+			op = &LotOperation{PartAndOperation: rec.PartAndOperation, FirstDate: date, LastDate: date}
+			operations[rec.PartAndOperation] = op
+			operators[rec.PartAndOperation] = make(map[string]bool)
+		}
+		if date < op.FirstDate {
+			op.FirstDate = date
+		}
+		if date > op.LastDate {
+			op.LastDate = date
+		}
+		op.TotalParts += rec.TotalParts
+		op.Defective += rec.Defective
+		op.GoodParts += rec.GoodParts
+		if !operators[rec.PartAndOperation][rec.FullName] {
+			operators[rec.PartAndOperation][rec.FullName] = true
+			op.Operators = append(op.Operators, rec.FullName)
+		}
+	}
+
+	// Сортируем записи по дате и операции, итоги - по дате начала операции
+	sort.SliceStable(trace.Entries, func(i, j int) bool {
+		if trace.Entries[i].Date != trace.Entries[j].Date {
+			return trace.Entries[i].Date < trace.Entries[j].Date
+		}
+		return trace.Entries[i].PartAndOperation < trace.Entries[j].PartAndOperation
+	})
+	for _, op := range operations {
+		trace.Operations = append(trace.Operations, *op)
+	}
+	sort.Slice(trace.Operations, func(i, j int) bool {
+		if trace.Operations[i].FirstDate != trace.Operations[j].FirstDate {
+			return trace.Operations[i].FirstDate < trace.Operations[j].FirstDate
+		}
+		return trace.Operations[i].PartAndOperation < trace.Operations[j].PartAndOperation
+	})
+	return trace
+}
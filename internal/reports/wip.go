@@ -0,0 +1,124 @@
+package reports
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/sergekovalev/siberia/internal/models"
+)
+
+// WIPStage содержит состояние одной операции маршрута
+type WIPStage struct {
+	Sequence  int    `json:"sequence"`          // Номер операции в маршруте
+	Operation string `json:"operation"`         // Деталь и операция
+	Incoming  int    `json:"incoming"`          // Поступило на операцию: годные с предыдущей операции или размер партии
+	Processed int    `json:"processed"`         // Обработано на операции (годные и брак)
+	GoodParts int    `json:"goodParts"`         // Годные детали после операции
+	Defective int    `json:"defective"`         // Брак на операции
+	Queue     int    `json:"queue"`             // Детали, ожидающие операции
+	Warning   string `json:"warning,omitempty"` // Предупреждение о расхождении количества
+}
+
+// WIPRoute содержит незавершенное производство детали в рамках партии
+type WIPRoute struct {
+	Part        string     `json:"part"`        // Деталь
+	Lot         string     `json:"lot"`         // Номер партии (пусто для записей без партии)
+	LotQuantity int        `json:"lotQuantity"` // Размер партии по заказу (0, если заказ не найден)
+	Stages      []WIPStage `json:"stages"`      // Операции маршрута
+	InProgress  int        `json:"inProgress"`  // Всего деталей между операциями
+	Finished    int        `json:"finished"`    // Годные детали после последней операции
+	Warnings    []string   `json:"warnings"`    // Предупреждения по маршруту
+}
+
+// wipKey идентифицирует маршрут детали в партии
+type wipKey struct {
+	part string
+	lot  string
+}
+
+// WIP рассчитывает незавершенное производство по маршрутам деталей из справочника операций
+// Очередь перед операцией = годные с предыдущей операции - обработанные на этой операции.
+// Для первой операции очередь считается только при известном размере партии из заказа
+func WIP(operations []models.Operation, records []models.ProductionRecord, orders []models.WorkOrder) []WIPRoute {
+	routings := models.Routings(operations)
+
+	// Определяем деталь и положение в маршруте для каждой операции
+	stageOf := make(map[string]models.Operation)
+	for _, route := range routings {
+		for _, op := range route {
+			stageOf[op.Name] = op
+		}
+	}
+
+	ordersByNumber := make(map[string]models.WorkOrder, len(orders))
+	for _, wo := range orders {
+		ordersByNumber[wo.Number] = wo
+	}
+
+	// Суммируем количество по операциям для каждой пары деталь/партия
+	type totals struct{ processed, good, defective int }
+	quantities := make(map[wipKey]map[string]*totals)
+	for _, rec := range records {
+		op, ok := stageOf[rec.PartAndOperation]
+		if !ok {
+			continue // Операция вне маршрутов
+		}
+		key := wipKey{part: op.Part, lot: rec.Lot}
+		if quantities[key] == nil {
+			quantities[key] = make(map[string]*totals)
+		}
+		t := quantities[key][op.Name]
+		if t == nil {
+			t = &totals{}
+			quantities[key][op.Name] = t
+		}
+		t.processed += rec.TotalParts
+		t.good += rec.GoodParts
+		t.defective += rec.Defective
+	}
+
+	result := make([]WIPRoute, 0, len(quantities))
+	for key, byOperation := range quantities {
+		route := WIPRoute{Part: key.part, Lot: key.lot, Stages: []WIPStage{}, Warnings: []string{}}
+		if wo, ok := ordersByNumber[key.lot]; ok && key.lot != "" {
+			route.LotQuantity = wo.Quantity
+		}
+
+		// Проходим операции маршрута по порядку, передавая годные детали на следующую операцию
+		incoming := route.LotQuantity
+		for i, op := range routings[key.part] {
+			stage := WIPStage{Sequence: op.Sequence, Operation: op.Name, Incoming: incoming}
+			if t := byOperation[op.Name]; t != nil {
+				stage.Processed = t.processed
+				stage.GoodParts = t.good
+				stage.Defective = t.defective
+			}
+
+			// Для первой операции без размера партии поступление неизвестно
+			known := i > 0 || route.LotQuantity > 0
+			if known {
+				stage.Queue = stage.Incoming - stage.Processed
+				if stage.Queue < 0 {
+					stage.Warning = fmt.Sprintf("%s: обработано %d шт., а поступило только %d шт.", op.Name, stage.Processed, stage.Incoming)
+					route.Warnings = append(route.Warnings, stage.Warning)
+					stage.Queue = 0
+				}
+				route.InProgress += stage.Queue
+			}
+
+			route.Stages = append(route.Stages, stage)
+			incoming = stage.GoodParts
+		}
+		route.Finished = incoming
+
+		result = append(result, route)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Part != result[j].Part {
+			return result[i].Part < result[j].Part
+		}
+		return result[i].Lot < result[j].Lot
+	})
+	return result
+}
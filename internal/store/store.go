@@ -0,0 +1,138 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Collection хранит записи одного типа в JSON-файле и держит их копию в памяти
+// Используется для служебных данных приложения, которые не нужно вести в Google Sheets
+type Collection[T any] struct {
+	mu    sync.Mutex
+	path  string
+	items []T
+}
+
+// Open загружает коллекцию из файла path; если файла нет, коллекция пуста
+func Open[T any](path string) (*Collection[T], error) {
+	c := &Collection[T]{path: path}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+	if err := json.Unmarshal(data, &c.items); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+	}
+	return c, nil
+}
+
+// All возвращает копию всех записей коллекции
+func (c *Collection[T]) All() []T {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]T(nil), c.items...)
+}
+
+// Find возвращает первую запись, удовлетворяющую условию match
+func (c *Collection[T]) Find(match func(T) bool) (T, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, item := range c.items {
+		if match(item) {
+			return item, true
+		}
+	}
+	var zero T
+	return zero, false
+}
+
+// Add добавляет запись и сохраняет коллекцию в файл
+func (c *Collection[T]) Add(item T) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.items = append(c.items, item)
+	if err := c.save(); err != nil {
+		c.items = c.items[:len(c.items)-1] // Откатываем изменение, если файл не записан
+		return err
+	}
+	return nil
+}
+
+// Update изменяет первую запись, удовлетворяющую условию match, и сохраняет коллекцию
+// Если update возвращает ошибку, запись не изменяется. Второй результат равен false, если запись не найдена
+func (c *Collection[T]) Update(match func(T) bool, update func(*T) error) (T, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i, item := range c.items {
+		if !match(item) {
+			continue
+		}
+		changed := item
+		if err := update(&changed); err != nil {
+			return item, true, err
+		}
+		c.items[i] = changed
+		if err := c.save(); err != nil {
+			c.items[i] = item // Откатываем изменение, если файл не записан
+			return item, true, err
+		}
+		return changed, true, nil
+	}
+	var zero T
+	return zero, false, nil
+}
+
+// Delete удаляет все записи, удовлетворяющие условию match, и возвращает их количество
+func (c *Collection[T]) Delete(match func(T) bool) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	kept := make([]T, 0, len(c.items))
+	for _, item := range c.items {
+		if !match(item) {
+			kept = append(kept, item)
+		}
+	}
+	removed := len(c.items) - len(kept)
+	if removed == 0 {
+		return 0, nil
+	}
+
+	previous := c.items
+	c.items = kept
+	if err := c.save(); err != nil {
+		c.items = previous // Откатываем изменение, если файл не записан
+		return 0, err
+	}
+	return removed, nil
+}
+
+// save записывает коллекцию во временный файл и атомарно заменяет им основной
+func (c *Collection[T]) save() error {
+	data, err := json.MarshalIndent(c.items, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %v", c.path, err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create data directory: %v", err)
+	}
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write %s: %v", tmp, err)
+	}
+	if err := os.Rename(tmp, c.path); err != nil {
+		return fmt.Errorf("failed to replace %s: %v", c.path, err)
+	}
+	return nil
+}
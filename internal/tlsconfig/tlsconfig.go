@@ -0,0 +1,127 @@
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sergekovalev/siberia/internal/config"
+)
+
+// checkInterval - как часто проверяется изменение файлов сертификатов
+const checkInterval = 10 * time.Second
+
+// reloader хранит текущие параметры TLS и перечитывает файлы, когда они изменились
+// Проверка выполняется при установке соединения, не чаще раза в checkInterval
+type reloader struct {
+	cfg config.TLS
+
+	mu      sync.Mutex
+	current *tls.Config
+	mtimes  map[string]time.Time // Время изменения файлов, из которых загружены текущие параметры
+	checked time.Time
+}
+
+// New загружает сертификат сервера и сертификаты центра устройств и возвращает параметры TLS
+// Замена файлов (например, продление сертификата) вступает в силу для новых соединений без перезапуска;
+// если новые файлы не загружаются, сервер продолжает работать с прежними и пишет ошибку в лог
+func New(cfg config.TLS) (*tls.Config, error) {
+	r := &reloader{cfg: cfg}
+	current, mtimes, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+	r.current, r.mtimes, r.checked = current, mtimes, time.Now()
+
+	return &tls.Config{
+		MinVersion:         current.MinVersion,
+		GetConfigForClient: r.configForClient,
+	}, nil
+}
+
+// configForClient возвращает параметры TLS для нового соединения, при необходимости перечитав файлы
+func (r *reloader) configForClient(*tls.ClientHelloInfo) (*tls.Config, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if time.Since(r.checked) < checkInterval {
+		return r.current, nil
+	}
+	r.checked = time.Now()
+	if !r.changed() {
+		return r.current, nil
+	}
+
+	current, mtimes, err := r.load()
+	if err != nil {
+		log.Printf("Error reloading TLS certificates, keeping previous: %v", err)
+		return r.current, nil
+	}
+	r.current, r.mtimes = current, mtimes
+	log.Printf("TLS certificates reloaded from %s", r.cfg.CertFile)
+	return r.current, nil
+}
+
+// changed проверяет, что хотя бы один из файлов изменился с момента загрузки
+func (r *reloader) changed() bool {
+	for file, mtime := range r.mtimes {
+		info, err := os.Stat(file)
+		if err != nil || !info.ModTime().Equal(mtime) {
+			return true
+		}
+	}
+	return false
+}
+
+// load читает файлы сертификатов и формирует параметры TLS
+func (r *reloader) load() (*tls.Config, map[string]time.Time, error) {
+	files := []string{r.cfg.CertFile, r.cfg.KeyFile}
+	if r.cfg.ClientCAFile != "" {
+		files = append(files, r.cfg.ClientCAFile)
+	}
+	// Время изменения запоминается до чтения: файл, замененный во время чтения, будет перечитан
+	mtimes := make(map[string]time.Time, len(files))
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, nil, err
+		}
+		mtimes[file] = info.ModTime()
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.cfg.CertFile, r.cfg.KeyFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load certificate: %v", err)
+	}
+
+	c := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+		NextProtos:   []string{"h2", "http/1.1"}, // Параметры соединения заменяют серверные целиком, включая HTTP/2
+	}
+	if r.cfg.MinVersion == "1.3" {
+		c.MinVersion = tls.VersionTLS13
+	}
+
+	// Сертификаты устройств проверяются, если они предъявлены; браузеры пользователей подключаются без них
+	if r.cfg.ClientCAFile != "" {
+		pem, err := os.ReadFile(r.cfg.ClientCAFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read client CA: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, nil, fmt.Errorf("no certificates found in %s", r.cfg.ClientCAFile)
+		}
+		c.ClientCAs = pool
+		c.ClientAuth = tls.VerifyClientCertIfGiven
+		if r.cfg.RequireClientCert {
+			c.ClientAuth = tls.RequireAndVerifyClientCert
+		}
+	}
+	return c, mtimes, nil
+}
@@ -0,0 +1,167 @@
+package utils
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LimitBody возвращает промежуточный обработчик, ограничивающий размер тела запроса maxBytes байтами
+// Чтение сверх ограничения завершается ошибкой *http.MaxBytesError, а запрос с большим
+// заявленным Content-Length отклоняется сразу с 413
+func LimitBody(maxBytes int64) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > maxBytes {
+				http.Error(w, "Request body too large, limit is "+strconv.FormatInt(maxBytes, 10)+" bytes", http.StatusRequestEntityTooLarge)
+				return
+			}
+			r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+			next(w, r)
+		}
+	}
+}
+
+// bucket - запас запросов одного клиента
+type bucket struct {
+	tokens float64   // Доступно запросов
+	last   time.Time // Время последнего пополнения
+}
+
+// RateLimiter ограничивает число запросов в минуту для каждого ключа (адреса или пользователя)
+// Запас пополняется равномерно, поэтому короткий всплеск до perMinute запросов допускается
+type RateLimiter struct {
+	mu      sync.Mutex
+	rate    float64 // Пополнение запаса, запросов в секунду
+	burst   float64 // Максимальный запас
+	buckets map[string]*bucket
+	swept   time.Time // Время последней очистки неактивных ключей
+}
+
+// NewRateLimiter создает ограничитель на perMinute запросов в минуту; 0 означает отсутствие ограничения
+func NewRateLimiter(perMinute int) *RateLimiter {
+	return &RateLimiter{
+		rate:    float64(perMinute) / 60,
+		burst:   float64(perMinute),
+		buckets: make(map[string]*bucket),
+		swept:   time.Now(),
+	}
+}
+
+// Allow расходует один запрос ключа key и сообщает, разрешен ли он
+// Если запрос не разрешен, второй результат - время до появления следующего запроса
+func (l *RateLimiter) Allow(key string) (bool, time.Duration) {
+	if l == nil || l.burst == 0 {
+		return true, 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	// Ключи, запас которых полностью восстановился, можно забыть
+	if now.Sub(l.swept) > time.Minute {
+		for k, b := range l.buckets {
+			if now.Sub(b.last).Seconds()*l.rate >= l.burst {
+				delete(l.buckets, k)
+			}
+		}
+		l.swept = now
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+	if b.tokens < 1 {
+		return false, time.Duration((1 - b.tokens) / l.rate * float64(time.Second))
+	}
+	b.tokens--
+	return true, 0
+}
+
+// TooManyRequests отвечает 429 с заголовком Retry-After (в секундах)
+func TooManyRequests(w http.ResponseWriter, retry time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retry.Seconds()))))
+	http.Error(w, "Too many requests, try again later", http.StatusTooManyRequests)
+}
+
+// RateLimit возвращает промежуточный обработчик, ограничивающий число запросов с одного адреса
+// За proxyHops доверенными обратными прокси адрес клиента берется из X-Forwarded-For (см. ClientIP)
+func RateLimit(limiter *RateLimiter, proxyHops int) func(http.HandlerFunc) http.HandlerFunc {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if ok, retry := limiter.Allow(ClientIP(r, proxyHops)); !ok {
+				TooManyRequests(w, retry)
+				return
+			}
+			next(w, r)
+		}
+	}
+}
+
+// ClientIP возвращает адрес клиента. За proxyHops доверенными обратными прокси адрес берется
+// из X-Forwarded-For на proxyHops позиций справа: каждый прокси дописывает адрес, с которого к нему
+// пришел запрос, а левые позиции задает сам клиент и доверять им нельзя.
+// Если адресов в заголовке меньше, используется адрес соединения
+func ClientIP(r *http.Request, proxyHops int) string {
+	if proxyHops > 0 {
+		var hops []string
+		for _, header := range r.Header.Values("X-Forwarded-For") {
+			hops = append(hops, strings.Split(header, ",")...)
+		}
+		if len(hops) >= proxyHops {
+			if ip := strings.TrimSpace(hops[len(hops)-proxyHops]); ip != "" {
+				return ip
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// SecurityHeaders добавляет к ответам со страницами приложения заголовки безопасности:
+// политику содержимого (CSP), запрет встраивания в чужие страницы и определения типа по содержимому.
+// Встраивать страницы в iframe могут только сайты из frameAncestors (например, интранет-портал)
+func SecurityHeaders(frameAncestors []string, next http.Handler) http.Handler {
+	ancestors := "'self'"
+	for _, origin := range frameAncestors {
+		if origin != "*" {
+			ancestors += " " + origin
+		}
+	}
+	csp := strings.Join([]string{
+		"default-src 'self'",
+		"script-src 'self' 'unsafe-inline'",
+		"style-src 'self' 'unsafe-inline' https://fonts.googleapis.com",
+		"font-src 'self' https://fonts.gstatic.com",
+		"img-src 'self' data:",
+		"connect-src 'self'",
+		"object-src 'none'",
+		"base-uri 'self'",
+		"form-action 'self'",
+		"frame-ancestors " + ancestors,
+	}, "; ")
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := w.Header()
+		h.Set("Content-Security-Policy", csp)
+		// X-Frame-Options не умеет перечислять сайты, поэтому задается, только если встраивание запрещено
+		if ancestors == "'self'" {
+			h.Set("X-Frame-Options", "SAMEORIGIN")
+		}
+		h.Set("X-Content-Type-Options", "nosniff")
+		h.Set("Referrer-Policy", "same-origin")
+		h.Set("Permissions-Policy", "camera=(), microphone=(), geolocation=()")
+		next.ServeHTTP(w, r)
+	})
+}
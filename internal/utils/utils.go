@@ -1,29 +1,117 @@
 package utils
 
 import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
 	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sergekovalev/siberia/internal/config"
 )
 
-// EnableCORS добавляет заголовки CORS (Cross-Origin Resource Sharing) к HTTP-ответу
-// Позволяет выполнять междоменные запросы (например, с фронтенда на другой домен)
-func EnableCORS(next http.HandlerFunc) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		// Устанавливаем заголовки для разрешения междоменных запросов
-		w.Header().Set("Access-Control-Allow-Origin", "*")              // Разрешаем запросы с любого домена
-		w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS") // Разрешаем методы POST и OPTIONS
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")  // Разрешаем заголовок Content-Type
-
-		// Если метод запроса OPTIONS, возвращаем статус 200 (OK) и завершаем обработку
-		if r.Method == "OPTIONS" {
-			w.WriteHeader(http.StatusOK)
-			return
+// CORS возвращает промежуточный обработчик, добавляющий заголовки CORS (Cross-Origin Resource Sharing)
+// по политике policy. Запросы со страниц самого приложения и без заголовка Origin пропускаются без изменений.
+// Предварительный запрос (OPTIONS) с разрешенного источника получает 204 с разрешенными методами
+// и заголовками; изменяющие запросы и предварительные запросы с других источников отклоняются с 403
+func CORS(policy config.CORS) func(http.HandlerFunc) http.HandlerFunc {
+	anyOrigin := false
+	origins := make(map[string]bool, len(policy.AllowedOrigins))
+	for _, origin := range policy.AllowedOrigins {
+		if origin == "*" {
+			anyOrigin = true
 		}
+		origins[origin] = true
+	}
+	methods := make(map[string]bool, len(policy.AllowedMethods))
+	for _, method := range policy.AllowedMethods {
+		methods[method] = true
+	}
+	allowMethods := strings.Join(policy.AllowedMethods, ", ")
+	allowHeaders := strings.Join(policy.AllowedHeaders, ", ")
+	maxAge := strconv.Itoa(policy.MaxAgeSeconds)
+
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			// Ответ зависит от источника: кэш не должен отдавать его другому сайту
+			w.Header().Add("Vary", "Origin")
+
+			origin := r.Header.Get("Origin")
+			if origin == "" || sameOrigin(origin, r) {
+				next(w, r)
+				return
+			}
 
-		// Передаем управление следующему обработчику
-		next(w, r)
+			allowed := anyOrigin || origins[strings.ToLower(origin)]
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+			if !allowed {
+				// Чтение браузер все равно не отдаст странице без заголовков CORS, а изменения не выполняем
+				if preflight || (r.Method != http.MethodGet && r.Method != http.MethodHead) {
+					http.Error(w, "Cross-origin request not allowed", http.StatusForbidden)
+					return
+				}
+				next(w, r)
+				return
+			}
+
+			if anyOrigin {
+				w.Header().Set("Access-Control-Allow-Origin", "*")
+			} else {
+				w.Header().Set("Access-Control-Allow-Origin", origin)
+			}
+			if policy.AllowCredentials {
+				w.Header().Set("Access-Control-Allow-Credentials", "true")
+			}
+
+			if preflight {
+				if !methods[strings.ToUpper(r.Header.Get("Access-Control-Request-Method"))] {
+					http.Error(w, "Cross-origin method not allowed", http.StatusForbidden)
+					return
+				}
+				w.Header().Set("Access-Control-Allow-Methods", allowMethods)
+				w.Header().Set("Access-Control-Allow-Headers", allowHeaders)
+				w.Header().Set("Access-Control-Max-Age", maxAge)
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			if !methods[r.Method] {
+				http.Error(w, "Cross-origin method not allowed", http.StatusForbidden)
+				return
+			}
+
+			// Передаем управление следующему обработчику
+			next(w, r)
+		}
 	}
 }
 
+// sameOrigin проверяет, что запрос отправлен со страницы самого приложения
+func sameOrigin(origin string, r *http.Request) bool {
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
+
+// formulaPrefixes - символы, с которых электронные таблицы начинают формулу
+// Табуляция и возврат каретки в начале значения также позволяют Excel выполнить формулу из CSV
+const formulaPrefixes = "=+-@\t\r"
+
+// numberPattern описывает число со знаком, в том числе с десятичной запятой: -5, +1.5, -0,25, 1e3
+var numberPattern = regexp.MustCompile(`^[+-]?(\d+([.,]\d*)?|[.,]\d+)([eE][+-]?\d+)?$`)
+
+// SafeCellText защищает значение ячейки Google Sheets или CSV от выполнения как формулы:
+// к тексту, начинающемуся с = + - @, добавляется апостроф, и таблица показывает его как текст.
+// Числа (например, -5) не изменяются и остаются числами
+func SafeCellText(s string) string {
+	if s == "" || !strings.ContainsRune(formulaPrefixes, rune(s[0])) || numberPattern.MatchString(s) {
+		return s
+	}
+	return "'" + s
+}
+
 // ColumnToLetter преобразует номер столбца (например, 1, 2, 3) в буквенное обозначение (например, A, B, C)
 // Используется для работы с адресами ячеек в Google Sheets
 func ColumnToLetter(col int) string {
@@ -35,3 +123,80 @@ func ColumnToLetter(col int) string {
 	}
 	return letter
 }
+
+// ParseNumber преобразует строковое значение ячейки в число
+// Учитывает форматирование Google Sheets: запятую как десятичный разделитель и пробелы между разрядами
+func ParseNumber(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	s = strings.ReplaceAll(s, "\u00a0", "") // Неразрывный пробел между разрядами
+	s = strings.ReplaceAll(s, " ", "")
+	s = strings.ReplaceAll(s, ",", ".")
+	return strconv.ParseFloat(s, 64)
+}
+
+// dateLayouts перечисляет форматы дат, в которых Google Sheets может вернуть значение ячейки
+var dateLayouts = []string{
+	"2006-01-02",
+	"2.1.2006",
+	"1/2/2006",
+}
+
+// ParseDate разбирает дату в формате YYYY-MM-DD или в одном из форматов отображения Google Sheets
+func ParseDate(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized date %q", s)
+}
+
+// ParseISOWeek разбирает неделю в формате YYYY-Www (например, 2025-W15) и возвращает понедельник этой недели
+func ParseISOWeek(s string) (time.Time, error) {
+	var year, week int
+	if _, err := fmt.Sscanf(strings.TrimSpace(s), "%d-W%d", &year, &week); err != nil || week < 1 || week > 53 {
+		return time.Time{}, fmt.Errorf("invalid week %q, expected YYYY-Www", s)
+	}
+
+	// 4 января всегда относится к первой ISO-неделе года
+	jan4 := time.Date(year, time.January, 4, 0, 0, 0, 0, time.UTC)
+	offset := (int(jan4.Weekday()) + 6) % 7 // Количество дней от понедельника
+	monday := jan4.AddDate(0, 0, -offset+(week-1)*7)
+
+	// Проверяем, что такая неделя существует в году (53-я неделя есть не в каждом году)
+	if y, w := monday.ISOWeek(); y != year || w != week {
+		return time.Time{}, fmt.Errorf("week %q does not exist", s)
+	}
+	return monday, nil
+}
+
+// dateTimeLayouts перечисляет форматы даты и времени, в которых Google Sheets может вернуть значение ячейки
+var dateTimeLayouts = []string{
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2.1.2006 15:04:05",
+	"2.1.2006 15:04",
+	"1/2/2006 15:04:05",
+	"1/2/2006 15:04",
+}
+
+// ParseDateTime разбирает дату и время в местном часовом поясе сервера
+func ParseDateTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range dateTimeLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized date and time %q", s)
+}
+
+// NewID генерирует случайный идентификатор записи из 8 шестнадцатеричных символов
+func NewID() string {
+	b := make([]byte, 4)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
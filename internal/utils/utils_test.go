@@ -0,0 +1,32 @@
+package utils
+
+import "testing"
+
+func TestSafeCellText(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"пустое значение", "", ""},
+		{"обычный текст", "Вал 01 - токарная", "Вал 01 - токарная"},
+		{"формула", "=HYPERLINK(\"http://x\")", "'=HYPERLINK(\"http://x\")"},
+		{"плюс в начале", "+cmd|' /C calc'!A0", "'+cmd|' /C calc'!A0"},
+		{"минус перед текстом", "-2+3+cmd", "'-2+3+cmd"},
+		{"собака в начале", "@SUM(A1:A2)", "'@SUM(A1:A2)"},
+		{"табуляция в начале", "\t=1+1", "'\t=1+1"},
+		{"возврат каретки в начале", "\r=1+1", "'\r=1+1"},
+		{"отрицательное число", "-5", "-5"},
+		{"число со знаком плюс", "+1.5", "+1.5"},
+		{"десятичная запятая", "-0,25", "-0,25"},
+		{"экспонента", "-1e3", "-1e3"},
+		{"знак без числа", "-", "'-"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SafeCellText(tt.in); got != tt.want {
+				t.Errorf("SafeCellText(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+// Проверка входа в систему для страниц приложения
+// Без действующей сессии страница перенаправляет на форму входа; после входа вверху страницы
+// показывается пользователь и кнопка выхода, а событие 'siberia:user' сообщает странице роль пользователя
+(async function () {
+    let user;
+    try {
+        const response = await fetch('/api/auth/me');
+        if (response.status === 401) {
+            location.href = '/login.html?next=' + encodeURIComponent(location.pathname + location.search);
+            return;
+        }
+        if (!response.ok) return;
+        user = await response.json();
+    } catch (error) {
+        console.error('Не удалось проверить вход:', error);
+        return;
+    }
+
+    const roles = {
+        operator: 'оператор',
+        foreman: 'мастер',
+        accountant: 'бухгалтер',
+        admin: 'администратор'
+    };
+
+    const bar = document.createElement('div');
+    bar.style.cssText = 'display:flex;justify-content:flex-end;align-items:center;gap:10px;padding:8px 16px;font-family:Arial,sans-serif;font-size:14px;color:#2c3e50;';
+    const name = document.createElement('span');
+    name.textContent = user.fullName + ' (' + (roles[user.role] || user.role) + ')';
+    const logout = document.createElement('button');
+    logout.textContent = 'Выйти';
+    logout.style.cssText = 'padding:4px 10px;border:1px solid #ddd;border-radius:4px;background:#fff;cursor:pointer;';
+    logout.onclick = async function () {
+        await fetch('/api/auth/logout', { method: 'POST' });
+        location.href = '/login.html';
+    };
+    bar.append(name, logout);
+
+    const show = function () {
+        document.body.prepend(bar);
+        window.currentUser = user;
+        document.dispatchEvent(new CustomEvent('siberia:user', { detail: user }));
+    };
+    if (document.readyState === 'loading') {
+        document.addEventListener('DOMContentLoaded', show);
+    } else {
+        show();
+    }
+})();